@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"path/filepath"
 	"phasor-frontend/internal/app"
 	"phasor-frontend/internal/config"
+	_ "time/tzdata" // The runtime image ships without a zoneinfo database, needed for clock.timezone.
 
 	"github.com/monkescience/vital"
 )
@@ -33,7 +35,7 @@ func main() {
 
 	templatesPath := filepath.Join("frontend", "internal", "frontend", "templates")
 
-	router, err := app.SetupRouter(cfg, templatesPath, logger)
+	router, err := app.SetupRouter(context.Background(), cfg, templatesPath, logger)
 	if err != nil {
 		log.Fatalf("failed to setup router: %v", err)
 	}
@@ -7,6 +7,7 @@ require (
 	github.com/monkescience/testastic v0.1.1
 	github.com/monkescience/vital v0.0.0-20251223172315-8503480c42fe
 	github.com/oapi-codegen/oapi-codegen/v2 v2.5.1
+	golang.org/x/net v0.49.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -29,7 +30,6 @@ require (
 	github.com/woodsbury/decimal128 v1.4.0 // indirect
 	go.yaml.in/yaml/v4 v4.0.0-rc.3 // indirect
 	golang.org/x/mod v0.31.0 // indirect
-	golang.org/x/net v0.49.0 // indirect
 	golang.org/x/sync v0.19.0 // indirect
 	golang.org/x/sys v0.40.0 // indirect
 	golang.org/x/term v0.39.0 // indirect
@@ -1,46 +1,124 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"phasor-frontend/internal/config"
 	"phasor-frontend/internal/frontend"
 	"phasor-frontend/internal/health"
+	"phasor-frontend/internal/inventory"
+	"phasor-frontend/internal/metrics"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/monkescience/vital"
 )
 
 // SetupRouter creates and configures the application router with all middleware and handlers.
-func SetupRouter(cfg *config.Config, templatesPath string, logger *slog.Logger) (*chi.Mux, error) {
+// Background work started by the router, such as health checks, stops when ctx is canceled.
+func SetupRouter(
+	ctx context.Context,
+	cfg *config.Config,
+	templatesPath string,
+	logger *slog.Logger,
+) (*chi.Mux, error) {
 	router := chi.NewRouter()
 	router.Use(vital.Recovery(logger))
 
-	backendChecker, err := health.NewBackendChecker(cfg.BackendURL)
+	// The backend health check probes the default target, the first resolved target.
+	defaultTarget := cfg.ResolvedTargets()[0]
+
+	backendTLS, err := buildTLS(defaultTarget.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure TLS of backend health checks: %w", err)
+	}
+
+	backendAuth, err := buildAuth(defaultTarget.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure auth of backend health checks: %w", err)
+	}
+
+	backendChecker, err := health.NewBackendChecker(
+		defaultTarget.URL,
+		health.WithInterval(cfg.HealthCheck.Interval),
+		health.WithTimeout(cfg.HealthCheck.Timeout),
+		health.WithTLSConfig(backendTLS),
+		health.WithRequestEditor(backendAuth),
+		health.WithFailurePolicy(health.FailurePolicy(cfg.HealthCheck.FailurePolicy)),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create backend health checker: %w", err)
 	}
 
+	backendChecker.Start(ctx)
+
 	healthHandler := vital.NewHealthHandler(
 		vital.WithEnvironment(cfg.Environment),
 		vital.WithCheckers(backendChecker),
 	)
 	router.Mount("/health", healthHandler)
 
+	registry := metrics.NewRegistry()
+	router.Handle("/metrics", registry)
+
+	location, err := cfg.Clock.Location()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load clock time zone: %w", err)
+	}
+
+	hostnamePatterns, err := cfg.CompiledHostnamePatterns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile hostname patterns: %w", err)
+	}
+
+	targets, err := buildTargets(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	frontendHandler, err := frontend.NewFrontendHandler(
 		templatesPath,
-		cfg.BackendURL,
+		targets,
 		cfg.TileColors,
+		frontend.WithInventory(inventory.NewStore(
+			inventory.WithStaleAfter(cfg.Inventory.StaleAfter),
+			inventory.WithExpireAfter(cfg.Inventory.ExpireAfter),
+			inventory.WithRestartWindow(cfg.Inventory.RestartWindow),
+			inventory.WithCrashLoopThreshold(cfg.Inventory.CrashLoopThreshold),
+		)),
+		frontend.WithSkewThreshold(cfg.Clock.SkewThreshold),
+		frontend.WithLocation(location),
+		frontend.WithLimits(buildLimits(cfg.RateLimit)),
+		frontend.WithMetrics(registry),
+		frontend.WithResultCache(cfg.Coalescing.CacheTTL),
+		frontend.WithRequestBudget(cfg.Sampling.Budget),
+		frontend.WithMinRefreshInterval(cfg.Refresh.MinInterval),
+		frontend.WithHostnamePatterns(hostnamePatterns),
+		frontend.WithColorAssignment(frontend.ColorAssignment(cfg.TileColorAssignment)),
+		frontend.WithColorBy(frontend.ColorBy{
+			Hostname: frontend.ColorAttribute(cfg.TileColorBy.Hostname),
+			Version:  frontend.ColorAttribute(cfg.TileColorBy.Version),
+		}),
+		frontend.WithVersionColors(cfg.VersionColors),
+		frontend.WithPalette(cfg.Accessibility.Palette),
+		frontend.WithMarkers(cfg.Accessibility.Markers),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create frontend handler: %w", err)
 	}
 
 	router.Group(func(r chi.Router) {
+		r.Use(frontend.KeepFlusher)
 		r.Use(vital.TraceContext())
 		r.Use(vital.RequestLogger(logger))
 		r.Get("/", frontendHandler.IndexHandler)
 		r.Get("/tiles", frontendHandler.TilesHandler)
+		r.Get("/instances", frontendHandler.InstancesHandler)
+		r.Get("/instances.json", frontendHandler.InstancesJSONHandler)
+		r.Get("/instances/{hostname}", frontendHandler.InstanceDetailHandler)
+		r.Get("/instances/{hostname}/hunt", frontendHandler.InstanceHuntHandler)
+		r.Get("/events", frontendHandler.EventsHandler)
+		r.Get("/events.json", frontendHandler.EventsJSONHandler)
 	})
 
 	return router, nil
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"math"
+	"phasor-frontend/internal/config"
+	"phasor-frontend/internal/frontend"
+	"phasor-frontend/internal/ratelimit"
+)
+
+// buildLimits creates the frontend limits from the configuration. A burst left at zero
+// defaults to one second worth of tokens.
+func buildLimits(cfg config.RateLimitConfig) frontend.Limits {
+	limits := frontend.Limits{
+		MaxConcurrentSamples: cfg.MaxConcurrentSamples,
+		TrustForwardedFor:    cfg.TrustForwardedFor,
+		Reject:               cfg.OnLimit == config.OnLimitReject,
+	}
+
+	if cfg.SamplesPerSecond > 0 {
+		limits.Samples = ratelimit.NewBucket(cfg.SamplesPerSecond, burst(cfg.SampleBurst, cfg.SamplesPerSecond))
+	}
+
+	if cfg.ClientRequestsPerSecond > 0 {
+		limits.Clients = ratelimit.NewClients(
+			cfg.ClientRequestsPerSecond,
+			burst(cfg.ClientBurst, cfg.ClientRequestsPerSecond),
+		)
+	}
+
+	return limits
+}
+
+func burst(configured int, rate float64) int {
+	if configured > 0 {
+		return configured
+	}
+
+	return max(1, int(math.Ceil(rate)))
+}
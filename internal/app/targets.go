@@ -0,0 +1,151 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"phasor-frontend/internal/auth"
+	"phasor-frontend/internal/config"
+	"phasor-frontend/internal/discovery"
+	"phasor-frontend/internal/frontend"
+	"phasor-frontend/internal/resilience"
+	"phasor-frontend/internal/tlsclient"
+)
+
+// buildTargets creates the frontend targets from the configuration. Pod discovery
+// of dns targets runs in the background until ctx is canceled.
+func buildTargets(ctx context.Context, cfg *config.Config) ([]frontend.Target, error) {
+	targetConfigs := cfg.ResolvedTargets()
+	targets := make([]frontend.Target, 0, len(targetConfigs))
+
+	for _, targetCfg := range targetConfigs {
+		tlsConfig, err := buildTLS(targetCfg.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure TLS of target %s: %w", targetCfg.Name, err)
+		}
+
+		editRequest, err := buildAuth(targetCfg.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure auth of target %s: %w", targetCfg.Name, err)
+		}
+
+		target := frontend.Target{
+			Name:           targetCfg.Name,
+			InstanceURL:    targetCfg.URL,
+			ExpectedSplit:  targetCfg.ExpectedSplit,
+			Policy:         buildPolicy(targetCfg.Resilience),
+			TLS:            tlsConfig,
+			EditRequest:    editRequest,
+			Headers:        buildHeaders(targetCfg.Headers),
+			ForwardHeaders: targetCfg.ForwardHeaders,
+			ForwardCookies: targetCfg.ForwardCookies,
+			CaptureHeaders: targetCfg.CaptureHeaders,
+			AffinityHeader: targetCfg.AffinityHeader,
+			PinHeader:      targetCfg.PinHeader,
+			Canary: frontend.CanaryRoute{
+				Header: targetCfg.Canary.Header,
+				Cookie: targetCfg.Canary.Cookie,
+				Value:  targetCfg.Canary.Value,
+			},
+		}
+
+		if targetCfg.Mode == config.TargetModeDNS {
+			recordType := discovery.RecordTypeA
+			if targetCfg.DNS.RecordType == "srv" {
+				recordType = discovery.RecordTypeSRV
+			}
+
+			watcher := discovery.NewWatcher(
+				discovery.NewResolver(targetCfg.DNS.Server),
+				targetCfg.DNS.Name,
+				recordType,
+				targetCfg.DNS.Port,
+				discovery.WithRefreshBounds(targetCfg.DNS.MinRefresh, targetCfg.DNS.MaxRefresh),
+			)
+			watcher.Start(ctx)
+
+			target.Pods = watcher
+			target.PodScheme = targetCfg.DNS.Scheme
+			target.PodPath = targetCfg.DNS.Path
+		}
+
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+// buildHeaders creates the static headers of a target.
+func buildHeaders(headers map[string]string) http.Header {
+	header := make(http.Header, len(headers))
+	for name, value := range headers {
+		header.Set(name, value)
+	}
+
+	return header
+}
+
+// buildAuth creates the request editor that authenticates requests to a target, or nil
+// when requests are sent without credentials.
+func buildAuth(cfg config.AuthConfig) (auth.RequestEditor, error) {
+	switch cfg.Type {
+	case config.AuthTypeBearer:
+		return auth.Bearer(cfg.Token), nil
+	case config.AuthTypeBearerFile:
+		editRequest, err := auth.BearerFile(cfg.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load bearer token: %w", err)
+		}
+
+		return editRequest, nil
+	case config.AuthTypeBasic:
+		return auth.Basic(cfg.Username, cfg.Password), nil
+	case config.AuthTypeHMAC:
+		return auth.HMAC(cfg.HMACSecret), nil
+	default:
+		return nil, nil
+	}
+}
+
+// buildTLS creates the TLS configuration of a target, or nil when it uses the defaults.
+func buildTLS(cfg config.TLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled() {
+		return nil, nil //nolint:nilnil // No TLS configuration means the transport defaults apply.
+	}
+
+	minVersion, err := tlsclient.ParseVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid min_version: %w", err)
+	}
+
+	opts := []tlsclient.Option{
+		tlsclient.WithServerName(cfg.ServerName),
+		tlsclient.WithMinVersion(minVersion),
+	}
+
+	if cfg.CAFile != "" {
+		opts = append(opts, tlsclient.WithCABundle(cfg.CAFile))
+	}
+
+	if cfg.CertFile != "" {
+		opts = append(opts, tlsclient.WithClientCertificate(cfg.CertFile, cfg.KeyFile))
+	}
+
+	tlsConfig, err := tlsclient.New(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build TLS configuration: %w", err)
+	}
+
+	return tlsConfig, nil
+}
+
+// buildPolicy creates the resilience policy of a target.
+func buildPolicy(cfg config.ResilienceConfig) *resilience.Policy {
+	return resilience.NewPolicy(
+		resilience.WithRetries(cfg.Retries, cfg.BackoffBase, cfg.BackoffMax),
+		resilience.WithHedging(cfg.HedgeAfter),
+		resilience.WithCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerOpenFor),
+		resilience.WithRetryable(frontend.IsRetryable),
+	)
+}
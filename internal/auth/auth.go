@@ -0,0 +1,68 @@
+// Package auth authenticates requests to backends with bearer tokens, basic auth or
+// HMAC signatures, without exposing the credentials in logs.
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// SignatureHeader carries the hex encoded HMAC-SHA256 signature of a request.
+	SignatureHeader = "X-Signature"
+	// TimestampHeader carries the Unix time the signature was created at.
+	TimestampHeader = "X-Signature-Timestamp"
+)
+
+// ErrEmptyToken is returned when a token file holds no token.
+var ErrEmptyToken = errors.New("token file is empty")
+
+// RequestEditor adds credentials to an outgoing request. It is called for every attempt,
+// so retried and hedged requests are authenticated on their own.
+type RequestEditor func(ctx context.Context, req *http.Request) error
+
+// Bearer sends token in the Authorization header.
+func Bearer(token Secret) RequestEditor {
+	return func(_ context.Context, req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token.Reveal())
+
+		return nil
+	}
+}
+
+// Basic authenticates with username and password.
+func Basic(username string, password Secret) RequestEditor {
+	return func(_ context.Context, req *http.Request) error {
+		req.SetBasicAuth(username, password.Reveal())
+
+		return nil
+	}
+}
+
+// HMAC signs the method, request URI and current time of every request with secret. The
+// signature and the time are sent in SignatureHeader and TimestampHeader.
+func HMAC(secret Secret) RequestEditor {
+	return func(_ context.Context, req *http.Request) error {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, Sign(secret, req.Method, req.URL.RequestURI(), timestamp))
+
+		return nil
+	}
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of method, request URI and timestamp, separated
+// by newlines. Backends verify requests by computing the same signature.
+func Sign(secret Secret, method, requestURI, timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(secret.Reveal()))
+	_, _ = mac.Write([]byte(method + "\n" + requestURI + "\n" + timestamp))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,38 @@
+package auth
+
+import "log/slog"
+
+const redacted = "[REDACTED]"
+
+// Secret is a credential that is redacted whenever it is formatted, logged or encoded.
+// Reveal returns the actual value.
+type Secret string
+
+// Reveal returns the value of the secret.
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// String redacts the secret.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+
+	return redacted
+}
+
+// GoString redacts the secret in %#v formatting.
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+// LogValue redacts the secret in structured logs.
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// MarshalText redacts the secret in JSON and other text encodings.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
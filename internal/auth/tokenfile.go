@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BearerFile sends the token stored in path in the Authorization header. The file is
+// re-read when it changes, e.g. when the kubelet rotates a projected service account token.
+func BearerFile(path string) (RequestEditor, error) {
+	file := &tokenFile{path: filepath.Clean(path)}
+
+	_, err := file.token()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(_ context.Context, req *http.Request) error {
+		token, err := file.token()
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token.Reveal())
+
+		return nil
+	}, nil
+}
+
+// tokenFile is a token that is re-read from disk when its file changes, safe for concurrent use.
+type tokenFile struct {
+	path string
+
+	mu      sync.Mutex
+	value   Secret
+	modTime time.Time
+	size    int64
+}
+
+// token returns the current token. While the file is unreadable or empty during a
+// rotation, the previous token is kept.
+func (f *tokenFile) token() (Secret, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		if f.value != "" {
+			return f.value, nil
+		}
+
+		return "", fmt.Errorf("failed to stat token file: %w", err)
+	}
+
+	if f.value != "" && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.value, nil
+	}
+
+	content, err := os.ReadFile(f.path)
+	if err != nil {
+		if f.value != "" {
+			return f.value, nil
+		}
+
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	value := Secret(strings.TrimSpace(string(content)))
+	if value == "" {
+		if f.value != "" {
+			return f.value, nil
+		}
+
+		return "", fmt.Errorf("%w: %s", ErrEmptyToken, f.path)
+	}
+
+	f.value = value
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+
+	return f.value, nil
+}
@@ -4,7 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"phasor-frontend/internal/auth"
+	"regexp"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -18,14 +23,250 @@ var (
 	ErrConfigPathNotAbsolute = errors.New("config file path must be absolute")
 	// ErrEnvironmentRequired is returned when environment is not configured in the config file.
 	ErrEnvironmentRequired = errors.New("environment must be configured in the config file")
+	// ErrInvalidColorAssignment is returned when tile_color_assignment has an unknown value.
+	ErrInvalidColorAssignment = errors.New("tile_color_assignment must be distinct or hash")
+	// ErrInvalidColorBy is returned when tile_color_by names an unknown attribute.
+	ErrInvalidColorBy = errors.New("tile_color_by must be hostname, version, revision, go_version or header:<name>")
+	// ErrInvalidPalette is returned when accessibility.palette names an unknown palette.
+	ErrInvalidPalette = errors.New("accessibility.palette must be tile_colors, okabe_ito, tol_bright, tol_muted or ibm")
+	// ErrInvalidFailurePolicy is returned when health_check.failure_policy has an unknown value.
+	ErrInvalidFailurePolicy = errors.New("health_check.failure_policy must be not_ready or degraded")
+	// ErrInvalidRateLimit is returned when rate_limit has negative values or an unknown on_limit.
+	ErrInvalidRateLimit = errors.New("invalid rate_limit")
+	// ErrInvalidHostnamePattern is returned when a hostname pattern does not compile or lacks a revision group.
+	ErrInvalidHostnamePattern = errors.New("invalid hostname_patterns")
+	// ErrInvalidTarget is returned when a configured target is incomplete or inconsistent.
+	ErrInvalidTarget = errors.New("invalid target")
 )
 
+const (
+	// DefaultTargetName is the name of the target built from backend_url.
+	DefaultTargetName = "default"
+	// TargetModeLB samples a target through its load-balanced URL.
+	TargetModeLB = "lb"
+	// TargetModeDNS samples every pod behind a headless Service DNS name directly.
+	TargetModeDNS = "dns"
+)
+
+// InventoryConfig holds the configuration of the sampled instance inventory.
+type InventoryConfig struct {
+	StaleAfter  time.Duration `yaml:"stale_after"`  // Instances not sampled for this long are marked stale
+	ExpireAfter time.Duration `yaml:"expire_after"` // Instances not sampled for this long are removed
+	// RestartWindow is the window in which restarts of an instance are counted.
+	RestartWindow time.Duration `yaml:"restart_window"`
+	// CrashLoopThreshold is the number of restarts within the window that flags an instance as crash looping.
+	CrashLoopThreshold int `yaml:"crash_loop_threshold"`
+}
+
+// TargetConfig describes a backend that can be sampled from the dashboard.
+type TargetConfig struct {
+	Name string    `yaml:"name"` // Name shown in the UI and used in the target query parameter
+	Mode string    `yaml:"mode"` // lb (default) or dns
+	URL  string    `yaml:"url"`  // Instance info URL, used in lb mode
+	DNS  DNSConfig `yaml:"dns"`  // Pod discovery settings, used in dns mode
+	// ExpectedSplit maps versions to their expected traffic share in percent, e.g. 1.2.0: 80.
+	// Glob patterns such as 1.2.* compare all the versions of a family as one share.
+	ExpectedSplit map[string]float64 `yaml:"expected_split"`
+	Resilience    ResilienceConfig   `yaml:"resilience"` // Retry, hedging and circuit breaker policy
+	TLS           TLSConfig          `yaml:"tls"`        // TLS settings of samples and health checks
+	Auth          AuthConfig         `yaml:"auth"`       // Credentials of samples and health checks
+	Headers       map[string]string  `yaml:"headers"`    // Headers sent with every sample
+	// ForwardHeaders and ForwardCookies allowlist browser headers and cookies forwarded to samples.
+	ForwardHeaders []string     `yaml:"forward_headers"`
+	ForwardCookies []string     `yaml:"forward_cookies"`
+	Canary         CanaryConfig `yaml:"canary"` // How the "route me to canary" toggle routes samples
+	// CaptureHeaders lists response headers shown on every tile and available for grouping.
+	CaptureHeaders []string `yaml:"capture_headers"`
+	// AffinityHeader carries a session per simulated client in sticky sampling, instead of cookies.
+	AffinityHeader string `yaml:"affinity_header"`
+	// PinHeader asks the routing layer to route a request to the hostname in its value. The
+	// instance detail page offers to hunt for a host only when it is set.
+	PinHeader string `yaml:"pin_header"`
+}
+
+// CanaryConfig describes how samples are routed to the canary of a target, by a header
+// or a cookie. The canary toggle has no effect when it is empty.
+type CanaryConfig struct {
+	Header string `yaml:"header"` // Header that routes to the canary, e.g. x-canary
+	Cookie string `yaml:"cookie"` // Cookie that routes to the canary, instead of a header
+	Value  string `yaml:"value"`  // Value of the header or cookie, e.g. always
+}
+
+// Values of AuthConfig.Type.
+const (
+	AuthTypeBearer     = "bearer"
+	AuthTypeBearerFile = "bearer_file"
+	AuthTypeBasic      = "basic"
+	AuthTypeHMAC       = "hmac"
+)
+
+// AuthConfig describes how requests to a target are authenticated. Requests are sent
+// without credentials when Type is empty.
+type AuthConfig struct {
+	Type       string      `yaml:"type"`        // bearer, bearer_file, basic or hmac
+	Token      auth.Secret `yaml:"token"`       // Static bearer token, used with bearer
+	TokenFile  string      `yaml:"token_file"`  // File holding the bearer token, re-read on change
+	Username   string      `yaml:"username"`    // Basic auth user, used with basic
+	Password   auth.Secret `yaml:"password"`    // Basic auth password, used with basic
+	HMACSecret auth.Secret `yaml:"hmac_secret"` // Shared secret that signs requests, used with hmac
+}
+
+// TLSConfig describes how connections to a target are secured. System roots and no client
+// certificate are used when left empty.
+type TLSConfig struct {
+	CAFile string `yaml:"ca_file"` // PEM bundle of the CAs that sign the server certificates
+	// CertFile and KeyFile hold the PEM client certificate and key for mutual TLS. They are
+	// reloaded when they change on disk.
+	CertFile   string `yaml:"cert_file"`
+	KeyFile    string `yaml:"key_file"`
+	ServerName string `yaml:"server_name"` // Name to verify the server certificate against
+	MinVersion string `yaml:"min_version"` // Minimum TLS version, 1.2 (default) or 1.3
+}
+
+// Enabled reports whether the TLS settings differ from the defaults.
+func (c TLSConfig) Enabled() bool {
+	return c != TLSConfig{}
+}
+
+// ResilienceConfig describes how samples of a target cope with a failing or slow backend.
+// Every mechanism is disabled when left at zero.
+type ResilienceConfig struct {
+	Retries          int           `yaml:"retries"`           // Maximum retries of a failed sample
+	BackoffBase      time.Duration `yaml:"backoff_base"`      // Backoff before the first retry, doubled per retry
+	BackoffMax       time.Duration `yaml:"backoff_max"`       // Upper bound of the backoff
+	HedgeAfter       time.Duration `yaml:"hedge_after"`       // Latency after which a hedged request is sent
+	BreakerThreshold int           `yaml:"breaker_threshold"` // Consecutive failed samples that open the circuit
+	BreakerOpenFor   time.Duration `yaml:"breaker_open_for"`  // How long an open circuit short-circuits samples
+}
+
+// DNSConfig describes how pods of a target are discovered through DNS.
+type DNSConfig struct {
+	Name       string        `yaml:"name"`        // Headless Service DNS name, expanded with the resolv.conf search list
+	RecordType string        `yaml:"record_type"` // a (A/AAAA, default) or srv
+	Port       int           `yaml:"port"`        // Pod port, used with A/AAAA records
+	Scheme     string        `yaml:"scheme"`      // URL scheme of the pods (default http)
+	Path       string        `yaml:"path"`        // Instance info path on the pods (default /instance/info)
+	Server     string        `yaml:"server"`      // DNS server queried with names as written (default /etc/resolv.conf)
+	MinRefresh time.Duration `yaml:"min_refresh"` // Lower bound of the TTL-driven refresh interval
+	MaxRefresh time.Duration `yaml:"max_refresh"` // Upper bound of the TTL-driven refresh interval
+}
+
+// HealthCheckConfig holds the configuration of the background backend health check.
+type HealthCheckConfig struct {
+	Interval      time.Duration `yaml:"interval"`       // How often the backend is checked
+	Timeout       time.Duration `yaml:"timeout"`        // Timeout of a single health request
+	FailurePolicy string        `yaml:"failure_policy"` // not_ready or degraded when the backend fails
+}
+
+// Values of RateLimitConfig.OnLimit.
+const (
+	OnLimitDegrade = "degrade"
+	OnLimitReject  = "reject"
+)
+
+// RateLimitConfig limits the load the dashboard puts on backends. Every limit is
+// disabled when left at zero.
+type RateLimitConfig struct {
+	SamplesPerSecond     float64 `yaml:"samples_per_second"`     // Outgoing samples per second across all clients
+	SampleBurst          int     `yaml:"sample_burst"`           // Outgoing samples allowed in a burst
+	MaxConcurrentSamples int     `yaml:"max_concurrent_samples"` // Outgoing samples in flight at once
+	// ClientRequestsPerSecond limits tile requests per client address.
+	ClientRequestsPerSecond float64 `yaml:"client_requests_per_second"`
+	ClientBurst             int     `yaml:"client_burst"`        // Tile requests per client allowed in a burst
+	TrustForwardedFor       bool    `yaml:"trust_forwarded_for"` // Identify clients by X-Forwarded-For
+	// OnLimit is degrade (default) to render cached tiles when a limit is hit, or reject to answer 429.
+	OnLimit string `yaml:"on_limit"`
+}
+
+// CoalescingConfig configures how identical tile requests share sampling results.
+type CoalescingConfig struct {
+	// CacheTTL is how long a sampling result is reused for identical requests. Concurrent
+	// identical requests share a sampling run even when it is zero.
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+}
+
+// SamplingConfig configures how tile requests sample the backend.
+type SamplingConfig struct {
+	// Budget bounds the time a tile request spends sampling. A shorter timeout query parameter
+	// lowers it per request. Samples still running when it runs out are shown as not sampled.
+	Budget time.Duration `yaml:"budget"`
+}
+
+// RefreshConfig configures the auto-refresh of the tiles.
+type RefreshConfig struct {
+	// MinInterval is the shortest auto-refresh interval; shorter ones chosen in the UI are raised to it,
+	// and polls within it of an identical poll reuse that result.
+	MinInterval time.Duration `yaml:"min_interval"`
+}
+
+// ColorByConfig chooses the attributes that drive the tile colors: hostname, version,
+// revision, go_version or header:<name> of a captured response header.
+type ColorByConfig struct {
+	// Hostname drives the color of the left border and the hostname. Defaults to hostname.
+	Hostname string `yaml:"hostname"`
+	// Version drives the color of the right border and the version. Defaults to revision,
+	// which falls back to the version for hostnames without a revision.
+	Version string `yaml:"version"`
+}
+
+// AccessibilityConfig configures color-blind-safe tile colors.
+type AccessibilityConfig struct {
+	// Palette replaces tile_colors with a color-blind-safe palette: okabe_ito, tol_bright,
+	// tol_muted or ibm. The palette query parameter chooses one per request.
+	Palette string `yaml:"palette"`
+	// Markers adds a shape per version color, so tiles do not differ by color only.
+	// The markers query parameter turns them on or off per request.
+	Markers bool `yaml:"markers"`
+}
+
+// ClockConfig holds the configuration of clock skew detection and timestamp rendering.
+type ClockConfig struct {
+	// SkewThreshold is the clock offset beyond which an instance is flagged, after
+	// accounting for the uncertainty of the measurement.
+	SkewThreshold time.Duration `yaml:"skew_threshold"`
+	// Timezone is the IANA name of the zone timestamps are shown in (default local time).
+	Timezone string `yaml:"timezone"`
+}
+
+// Location returns the time zone timestamps are shown in.
+func (c ClockConfig) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clock.timezone %q: %w", c.Timezone, err)
+	}
+
+	return location, nil
+}
+
 // Config holds the frontend application configuration.
 type Config struct {
-	BackendURL  string   `yaml:"backend_url"` // URL of the backend service
-	Environment string   `yaml:"environment"` // Environment name (e.g., local, dev, staging, prod)
-	TileColors  []string `yaml:"tile_colors"` // Colors for instance tiles
-	LogConfig   struct {
+	BackendURL  string            `yaml:"backend_url"`  // URL of the backend service
+	Environment string            `yaml:"environment"`  // Environment name (e.g., local, dev, staging, prod)
+	TileColors  []string          `yaml:"tile_colors"`  // Colors for instance tiles
+	HealthCheck HealthCheckConfig `yaml:"health_check"` // Backend health check settings
+	Targets     []TargetConfig    `yaml:"targets"`      // Additional targets besides backend_url
+	Inventory   InventoryConfig   `yaml:"inventory"`    // Instance inventory settings
+	Clock       ClockConfig       `yaml:"clock"`        // Clock skew and timestamp settings
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`   // Limits on tile requests and outgoing samples
+	Coalescing  CoalescingConfig  `yaml:"coalescing"`   // Sharing of sampling results between requests
+	Sampling    SamplingConfig    `yaml:"sampling"`     // Time budget of tile requests
+	Refresh     RefreshConfig     `yaml:"refresh"`      // Auto-refresh of the tiles
+	// TileColorAssignment is distinct to give distinct instances of a view distinct colors,
+	// generating extra ones when tile_colors runs out, or hash to always hash to a tile color.
+	TileColorAssignment string `yaml:"tile_color_assignment"`
+	// TileColorBy chooses the attributes that drive the hostname and version colors of tiles.
+	TileColorBy ColorByConfig `yaml:"tile_color_by"`
+	// VersionColors pins the colors of versions, e.g. stable to blue and canary to orange.
+	VersionColors map[string]string   `yaml:"version_colors"`
+	Accessibility AccessibilityConfig `yaml:"accessibility"` // Color-blind-safe palettes and markers
+	// HostnamePatterns split hostnames into workload, revision and pod with the named groups
+	// workload, revision and pod. Kubernetes pod naming is used when empty.
+	HostnamePatterns []string `yaml:"hostname_patterns"`
+	LogConfig        struct {
 		Level     string `yaml:"level"`      // Log level (debug, info, warn, error)
 		Format    string `yaml:"format"`     // Log format (json, text)
 		AddSource bool   `yaml:"add_source"` // Include source file and line number
@@ -72,5 +313,284 @@ func Load(path string) (*Config, error) {
 		return nil, ErrTileColorsRequired
 	}
 
+	switch cfg.TileColorAssignment {
+	case "", "distinct", "hash":
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidColorAssignment, cfg.TileColorAssignment)
+	}
+
+	err = validateColorBy(cfg.TileColorBy)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Accessibility.Palette {
+	case "", "tile_colors", "okabe_ito", "tol_bright", "tol_muted", "ibm":
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPalette, cfg.Accessibility.Palette)
+	}
+
+	switch cfg.HealthCheck.FailurePolicy {
+	case "", "not_ready", "degraded":
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFailurePolicy, cfg.HealthCheck.FailurePolicy)
+	}
+
+	err = validateRateLimit(cfg.RateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = cfg.Clock.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = cfg.CompiledHostnamePatterns()
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateTargets(cfg.ResolvedTargets())
+	if err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
+
+// CompiledHostnamePatterns compiles the hostname patterns. Every pattern must have a revision group.
+func (c *Config) CompiledHostnamePatterns() ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(c.HostnamePatterns))
+
+	for _, expr := range c.HostnamePatterns {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidHostnamePattern, err)
+		}
+
+		if pattern.SubexpIndex("revision") < 0 {
+			return nil, fmt.Errorf("%w: %s has no revision group", ErrInvalidHostnamePattern, expr)
+		}
+
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns, nil
+}
+
+// ResolvedTargets returns all targets, starting with the default target built from backend_url.
+// A configured target named "default" overrides the built-in one and inherits backend_url.
+func (c *Config) ResolvedTargets() []TargetConfig {
+	defaultTarget := TargetConfig{Name: DefaultTargetName, Mode: TargetModeLB, URL: c.BackendURL}
+	targets := []TargetConfig{defaultTarget}
+
+	for _, target := range c.Targets {
+		if target.Mode == "" {
+			target.Mode = TargetModeLB
+		}
+
+		if target.Name != DefaultTargetName {
+			targets = append(targets, target)
+
+			continue
+		}
+
+		if target.URL == "" {
+			target.URL = c.BackendURL
+		}
+
+		targets[0] = target
+	}
+
+	return targets
+}
+
+func validateColorBy(colorBy ColorByConfig) error {
+	for _, attribute := range []string{colorBy.Hostname, colorBy.Version} {
+		switch attribute {
+		case "", "hostname", "version", "revision", "go_version":
+		default:
+			if name, found := strings.CutPrefix(attribute, "header:"); !found || name == "" {
+				return fmt.Errorf("%w: %s", ErrInvalidColorBy, attribute)
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateRateLimit(rateLimit RateLimitConfig) error {
+	if rateLimit.SamplesPerSecond < 0 || rateLimit.SampleBurst < 0 || rateLimit.MaxConcurrentSamples < 0 ||
+		rateLimit.ClientRequestsPerSecond < 0 || rateLimit.ClientBurst < 0 {
+		return fmt.Errorf("%w: values must not be negative", ErrInvalidRateLimit)
+	}
+
+	switch rateLimit.OnLimit {
+	case "", OnLimitDegrade, OnLimitReject:
+	default:
+		return fmt.Errorf("%w: on_limit must be degrade or reject: %s", ErrInvalidRateLimit, rateLimit.OnLimit)
+	}
+
+	return nil
+}
+
+func validateTargets(targets []TargetConfig) error {
+	seen := make(map[string]bool, len(targets))
+
+	for _, target := range targets {
+		if target.Name == "" {
+			return fmt.Errorf("%w: name must be set", ErrInvalidTarget)
+		}
+
+		if seen[target.Name] {
+			return fmt.Errorf("%w: duplicate name %s", ErrInvalidTarget, target.Name)
+		}
+
+		seen[target.Name] = true
+
+		for version, share := range target.ExpectedSplit {
+			if share < 0 {
+				return fmt.Errorf("%w: %s: negative expected_split share for %s", ErrInvalidTarget, target.Name, version)
+			}
+
+			_, err := path.Match(version, "")
+			if err != nil {
+				return fmt.Errorf("%w: %s: invalid expected_split pattern %s", ErrInvalidTarget, target.Name, version)
+			}
+		}
+
+		err := validateResilience(target)
+		if err != nil {
+			return err
+		}
+
+		err = validateTLS(target)
+		if err != nil {
+			return err
+		}
+
+		err = validateAuth(target)
+		if err != nil {
+			return err
+		}
+
+		err = validateCanary(target)
+		if err != nil {
+			return err
+		}
+
+		switch target.Mode {
+		case TargetModeLB:
+			if target.URL == "" {
+				return fmt.Errorf("%w: %s: url must be set in lb mode", ErrInvalidTarget, target.Name)
+			}
+		case TargetModeDNS:
+			err := validateDNS(target)
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: %s: unknown mode %s", ErrInvalidTarget, target.Name, target.Mode)
+		}
+	}
+
+	return nil
+}
+
+func validateResilience(target TargetConfig) error {
+	resilience := target.Resilience
+
+	if resilience.Retries < 0 || resilience.BreakerThreshold < 0 {
+		return fmt.Errorf("%w: %s: resilience counts must not be negative", ErrInvalidTarget, target.Name)
+	}
+
+	if resilience.BackoffBase < 0 || resilience.BackoffMax < 0 || resilience.HedgeAfter < 0 ||
+		resilience.BreakerOpenFor < 0 {
+		return fmt.Errorf("%w: %s: resilience durations must not be negative", ErrInvalidTarget, target.Name)
+	}
+
+	return nil
+}
+
+func validateTLS(target TargetConfig) error {
+	tlsCfg := target.TLS
+
+	if (tlsCfg.CertFile == "") != (tlsCfg.KeyFile == "") {
+		return fmt.Errorf("%w: %s: tls.cert_file and tls.key_file must be set together", ErrInvalidTarget, target.Name)
+	}
+
+	switch tlsCfg.MinVersion {
+	case "", "1.2", "1.3":
+	default:
+		return fmt.Errorf("%w: %s: tls.min_version must be 1.2 or 1.3", ErrInvalidTarget, target.Name)
+	}
+
+	return nil
+}
+
+func validateAuth(target TargetConfig) error {
+	authCfg := target.Auth
+
+	var missing string
+
+	switch authCfg.Type {
+	case "":
+	case AuthTypeBearer:
+		if authCfg.Token == "" {
+			missing = "auth.token"
+		}
+	case AuthTypeBearerFile:
+		if authCfg.TokenFile == "" {
+			missing = "auth.token_file"
+		}
+	case AuthTypeBasic:
+		if authCfg.Username == "" {
+			missing = "auth.username"
+		}
+	case AuthTypeHMAC:
+		if authCfg.HMACSecret == "" {
+			missing = "auth.hmac_secret"
+		}
+	default:
+		return fmt.Errorf("%w: %s: unknown auth.type %s", ErrInvalidTarget, target.Name, authCfg.Type)
+	}
+
+	if missing != "" {
+		return fmt.Errorf("%w: %s: %s must be set with auth.type %s", ErrInvalidTarget, target.Name, missing, authCfg.Type)
+	}
+
+	return nil
+}
+
+func validateCanary(target TargetConfig) error {
+	canary := target.Canary
+
+	if canary.Header != "" && canary.Cookie != "" {
+		return fmt.Errorf("%w: %s: canary.header and canary.cookie are exclusive", ErrInvalidTarget, target.Name)
+	}
+
+	if (canary.Header != "" || canary.Cookie != "") && canary.Value == "" {
+		return fmt.Errorf("%w: %s: canary.value must be set", ErrInvalidTarget, target.Name)
+	}
+
+	return nil
+}
+
+func validateDNS(target TargetConfig) error {
+	if target.DNS.Name == "" {
+		return fmt.Errorf("%w: %s: dns.name must be set in dns mode", ErrInvalidTarget, target.Name)
+	}
+
+	switch target.DNS.RecordType {
+	case "", "a":
+		if target.DNS.Port <= 0 {
+			return fmt.Errorf("%w: %s: dns.port must be set for A records", ErrInvalidTarget, target.Name)
+		}
+	case "srv":
+	default:
+		return fmt.Errorf("%w: %s: unknown dns.record_type %s", ErrInvalidTarget, target.Name, target.DNS.RecordType)
+	}
+
+	return nil
+}
@@ -0,0 +1,451 @@
+// Package discovery enumerates backend pods through DNS records of a headless Service.
+package discovery
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	resolvConfPath     = "/etc/resolv.conf"
+	defaultNameserver  = "127.0.0.1:53"
+	dnsPort            = "53"
+	dnsQueryTimeout    = 2 * time.Second
+	ednsUDPPayloadSize = 4096
+	tcpLengthPrefix    = 2
+	// defaultNdots is the number of dots from which a name is tried as is before the search list.
+	defaultNdots = 1
+)
+
+var (
+	// ErrDNSResponse is returned when the DNS server answers with an error code.
+	ErrDNSResponse = errors.New("DNS server returned an error")
+	// ErrDNSMismatch is returned when a DNS response does not belong to the sent query.
+	ErrDNSMismatch = errors.New("DNS response does not match query")
+)
+
+// Resolver queries DNS servers and exposes record TTLs, which the standard library
+// resolver hides.
+type Resolver struct {
+	// servers are tried in order until one answers.
+	servers []string
+	// search and ndots expand relative names like the system resolver does.
+	search  []string
+	ndots   int
+	timeout time.Duration
+}
+
+// NewResolver creates a resolver for the given DNS server address (host or host:port),
+// which gets names as they are written. When server is empty, the resolver is configured
+// from /etc/resolv.conf.
+func NewResolver(server string) *Resolver {
+	if server == "" {
+		return NewResolverFromConfig(resolvConfPath)
+	}
+
+	return &Resolver{
+		servers: []string{withDNSPort(server)},
+		ndots:   defaultNdots,
+		timeout: dnsQueryTimeout,
+	}
+}
+
+// NewResolverFromConfig creates a resolver from a resolv.conf file. It queries the
+// nameservers in order until one answers, and expands relative names with the search
+// list: names with fewer dots than ndots, such as a short Service name, are tried with
+// every search domain first, other names are tried as they are first.
+func NewResolverFromConfig(path string) *Resolver {
+	resolver := &Resolver{ndots: defaultNdots, timeout: dnsQueryTimeout}
+
+	file, err := os.Open(path) //nolint:gosec // The path is the resolver configuration, not user input.
+	if err == nil {
+		defer func() {
+			_ = file.Close()
+		}()
+
+		resolver.readConfig(file)
+	}
+
+	if len(resolver.servers) == 0 {
+		resolver.servers = []string{defaultNameserver}
+	}
+
+	return resolver
+}
+
+// readConfig reads the nameserver, search, domain and ndots settings of a resolv.conf file.
+func (r *Resolver) readConfig(reader io.Reader) {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "nameserver":
+			r.servers = append(r.servers, withDNSPort(fields[1]))
+		case "search", "domain":
+			r.search = fields[1:]
+		case "options":
+			for _, option := range fields[1:] {
+				value, found := strings.CutPrefix(option, "ndots:")
+				if !found {
+					continue
+				}
+
+				ndots, err := strconv.Atoi(value)
+				if err == nil && ndots >= 0 {
+					r.ndots = ndots
+				}
+			}
+		}
+	}
+}
+
+// names returns the absolute names to try for name, in order.
+func (r *Resolver) names(name string) []string {
+	if strings.HasSuffix(name, ".") || len(r.search) == 0 {
+		return []string{absoluteName(name)}
+	}
+
+	expanded := make([]string, 0, len(r.search)+1)
+	for _, domain := range r.search {
+		expanded = append(expanded, absoluteName(name+"."+domain))
+	}
+
+	if strings.Count(name, ".") >= r.ndots {
+		return append([]string{absoluteName(name)}, expanded...)
+	}
+
+	return append(expanded, absoluteName(name))
+}
+
+// LookupHosts resolves A and AAAA records of name, trying the names of the search list
+// until one has addresses. It returns the addresses and the smallest TTL of all
+// returned records.
+func (r *Resolver) LookupHosts(ctx context.Context, name string) ([]netip.Addr, time.Duration, error) {
+	for _, candidate := range r.names(name) {
+		addrs, ttl, err := r.lookupHosts(ctx, candidate)
+		if err != nil || len(addrs) > 0 {
+			return addrs, ttl, err
+		}
+	}
+
+	return nil, 0, nil
+}
+
+// lookupHosts resolves A and AAAA records of an absolute name.
+func (r *Resolver) lookupHosts(ctx context.Context, name string) ([]netip.Addr, time.Duration, error) {
+	var (
+		addrs  []netip.Addr
+		minTTL uint32
+	)
+
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		msg, err := r.query(ctx, name, qtype)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, answer := range msg.Answers {
+			switch body := answer.Body.(type) {
+			case *dnsmessage.AResource:
+				addrs = append(addrs, netip.AddrFrom4(body.A))
+			case *dnsmessage.AAAAResource:
+				addrs = append(addrs, netip.AddrFrom16(body.AAAA))
+			default:
+				continue
+			}
+
+			minTTL = lowerTTL(minTTL, answer.Header.TTL)
+		}
+	}
+
+	return addrs, ttlDuration(minTTL), nil
+}
+
+// LookupSRV resolves SRV records of name into endpoints, trying the names of the search
+// list until one has records. Targets are resolved from the additional section of the
+// response, or with a separate host lookup when missing.
+func (r *Resolver) LookupSRV(ctx context.Context, name string) ([]Endpoint, time.Duration, error) {
+	for _, candidate := range r.names(name) {
+		endpoints, ttl, err := r.lookupSRV(ctx, candidate)
+		if err != nil || len(endpoints) > 0 {
+			return endpoints, ttl, err
+		}
+	}
+
+	return nil, 0, nil
+}
+
+// lookupSRV resolves SRV records of an absolute name into endpoints.
+func (r *Resolver) lookupSRV(ctx context.Context, name string) ([]Endpoint, time.Duration, error) {
+	msg, err := r.query(ctx, name, dnsmessage.TypeSRV)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	glue := make(map[string][]netip.Addr)
+
+	for _, additional := range msg.Additionals {
+		host := additional.Header.Name.String()
+
+		switch body := additional.Body.(type) {
+		case *dnsmessage.AResource:
+			glue[host] = append(glue[host], netip.AddrFrom4(body.A))
+		case *dnsmessage.AAAAResource:
+			glue[host] = append(glue[host], netip.AddrFrom16(body.AAAA))
+		default:
+		}
+	}
+
+	var (
+		endpoints []Endpoint
+		minTTL    uint32
+	)
+
+	for _, answer := range msg.Answers {
+		srv, ok := answer.Body.(*dnsmessage.SRVResource)
+		if !ok {
+			continue
+		}
+
+		minTTL = lowerTTL(minTTL, answer.Header.TTL)
+		host := srv.Target.String()
+
+		addrs, found := glue[host]
+		if !found {
+			var hostTTL time.Duration
+
+			addrs, hostTTL, err = r.LookupHosts(ctx, host)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			minTTL = lowerTTL(minTTL, uint32(hostTTL.Seconds()))
+		}
+
+		for _, addr := range addrs {
+			endpoints = append(endpoints, Endpoint{Host: addr.String(), Port: int(srv.Port)})
+		}
+	}
+
+	return endpoints, ttlDuration(minTTL), nil
+}
+
+// query sends a single question to the DNS servers in order, until one answers.
+func (r *Resolver) query(ctx context.Context, name string, qtype dnsmessage.Type) (*dnsmessage.Message, error) {
+	packed, id, err := buildQuery(name, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg *dnsmessage.Message
+
+	for _, server := range r.servers {
+		msg, err = r.queryServer(ctx, server, packed, id)
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.RCode != dnsmessage.RCodeSuccess && msg.RCode != dnsmessage.RCodeNameError {
+		return nil, fmt.Errorf("%w: %s for %s", ErrDNSResponse, msg.RCode, name)
+	}
+
+	return msg, nil
+}
+
+// queryServer sends a packed query to a DNS server. It uses UDP and falls back to TCP
+// when the response is truncated.
+func (r *Resolver) queryServer(
+	ctx context.Context, server string, packed []byte, id uint16,
+) (*dnsmessage.Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	msg, err := exchange(ctx, server, "udp", packed, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.Truncated {
+		msg, err = exchange(ctx, server, "tcp", packed, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return msg, nil
+}
+
+// exchange sends a packed query to server over the given network and parses the response.
+func exchange(ctx context.Context, server, network string, packed []byte, id uint16) (*dnsmessage.Message, error) {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to DNS server: %w", err)
+	}
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	deadline, _ := ctx.Deadline()
+
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set DNS deadline: %w", err)
+	}
+
+	response, err := roundTrip(conn, network, packed)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg dnsmessage.Message
+
+	err = msg.Unpack(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DNS response: %w", err)
+	}
+
+	if msg.ID != id || !msg.Response {
+		return nil, ErrDNSMismatch
+	}
+
+	return &msg, nil
+}
+
+// roundTrip writes a query to conn and reads the response, using length prefixes on TCP.
+func roundTrip(conn net.Conn, network string, packed []byte) ([]byte, error) {
+	if network == "udp" {
+		_, err := conn.Write(packed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send DNS query: %w", err)
+		}
+
+		buf := make([]byte, ednsUDPPayloadSize)
+
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read DNS response: %w", err)
+		}
+
+		return buf[:n], nil
+	}
+
+	framed := binary.BigEndian.AppendUint16(nil, uint16(len(packed))) //nolint:gosec // DNS messages are below 64KiB.
+
+	_, err := conn.Write(append(framed, packed...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to send DNS query: %w", err)
+	}
+
+	prefix := make([]byte, tcpLengthPrefix)
+
+	_, err = io.ReadFull(conn, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DNS response: %w", err)
+	}
+
+	buf := make([]byte, binary.BigEndian.Uint16(prefix))
+
+	_, err = io.ReadFull(conn, buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DNS response: %w", err)
+	}
+
+	return buf, nil
+}
+
+// buildQuery packs a recursive query for name with an EDNS0 record that allows large UDP responses.
+func buildQuery(name string, qtype dnsmessage.Type) ([]byte, uint16, error) {
+	qname, err := dnsmessage.NewName(absoluteName(name))
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid DNS name %q: %w", name, err)
+	}
+
+	idBytes := make([]byte, tcpLengthPrefix)
+
+	_, err = rand.Read(idBytes)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to generate DNS query ID: %w", err)
+	}
+
+	id := binary.BigEndian.Uint16(idBytes)
+
+	var opt dnsmessage.ResourceHeader
+
+	err = opt.SetEDNS0(ednsUDPPayloadSize, dnsmessage.RCodeSuccess, false)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to set EDNS0 options: %w", err)
+	}
+
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: qname, Type: qtype, Class: dnsmessage.ClassINET},
+		},
+		Additionals: []dnsmessage.Resource{
+			{Header: opt, Body: &dnsmessage.OPTResource{}},
+		},
+	}
+
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to pack DNS query: %w", err)
+	}
+
+	return packed, id, nil
+}
+
+// absoluteName returns name with a trailing dot.
+func absoluteName(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+
+	return name + "."
+}
+
+// withDNSPort adds the DNS port to a server address without one.
+func withDNSPort(server string) string {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		return net.JoinHostPort(server, dnsPort)
+	}
+
+	return server
+}
+
+// lowerTTL returns the smaller of two TTLs, treating zero as unset.
+func lowerTTL(current, ttl uint32) uint32 {
+	if current == 0 || ttl < current {
+		return ttl
+	}
+
+	return current
+}
+
+func ttlDuration(ttl uint32) time.Duration {
+	return time.Duration(ttl) * time.Second
+}
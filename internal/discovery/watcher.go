@@ -0,0 +1,225 @@
+package discovery
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMinRefresh = time.Second
+	defaultMaxRefresh = 30 * time.Second
+)
+
+// RecordType selects which DNS records are used to enumerate endpoints.
+type RecordType string
+
+const (
+	// RecordTypeA enumerates endpoints from A and AAAA records combined with a fixed port.
+	RecordTypeA RecordType = "a"
+	// RecordTypeSRV enumerates endpoints from SRV records, which carry their own ports.
+	RecordTypeSRV RecordType = "srv"
+)
+
+// ErrNoEndpoints is returned when a DNS name resolves to no endpoints.
+var ErrNoEndpoints = errors.New("DNS name resolved to no endpoints")
+
+// Endpoint is a single pod address discovered through DNS.
+type Endpoint struct {
+	Host string
+	Port int
+}
+
+// Address returns the endpoint as host:port.
+func (e Endpoint) Address() string {
+	return net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+}
+
+// WatcherOption configures a Watcher.
+type WatcherOption func(*Watcher)
+
+// WithRefreshBounds clamps the TTL-driven refresh interval to [minRefresh, maxRefresh].
+func WithRefreshBounds(minRefresh, maxRefresh time.Duration) WatcherOption {
+	return func(w *Watcher) {
+		if minRefresh > 0 {
+			w.minRefresh = minRefresh
+		}
+
+		if maxRefresh > 0 {
+			w.maxRefresh = maxRefresh
+		}
+	}
+}
+
+// Watcher keeps the endpoints behind a DNS name up to date, refreshing them when
+// the TTL of the records expires.
+type Watcher struct {
+	resolver   *Resolver
+	name       string
+	recordType RecordType
+	port       int
+	minRefresh time.Duration
+	maxRefresh time.Duration
+
+	refreshMu sync.Mutex
+	mu        sync.RWMutex
+	resolved  bool
+	endpoints []Endpoint
+	err       error
+}
+
+// NewWatcher creates a watcher for name. For RecordTypeA, port is used for every address.
+func NewWatcher(
+	resolver *Resolver,
+	name string,
+	recordType RecordType,
+	port int,
+	opts ...WatcherOption,
+) *Watcher {
+	watcher := &Watcher{
+		resolver:   resolver,
+		name:       name,
+		recordType: recordType,
+		port:       port,
+		minRefresh: defaultMinRefresh,
+		maxRefresh: defaultMaxRefresh,
+	}
+
+	for _, opt := range opts {
+		opt(watcher)
+	}
+
+	return watcher
+}
+
+// Start refreshes the endpoints in the background until the context is canceled.
+func (w *Watcher) Start(ctx context.Context) {
+	go func() {
+		for {
+			wait := w.refresh(ctx)
+
+			timer := time.NewTimer(wait)
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+// Endpoints returns the most recently resolved endpoints. Until the first background
+// refresh has completed, the name is resolved synchronously. The error describes the
+// last failed lookup and is only returned when no endpoints are known.
+func (w *Watcher) Endpoints(ctx context.Context) ([]Endpoint, error) {
+	w.mu.RLock()
+	resolved := w.resolved
+	w.mu.RUnlock()
+
+	if !resolved {
+		w.refreshMu.Lock()
+
+		w.mu.RLock()
+		resolved = w.resolved
+		w.mu.RUnlock()
+
+		if !resolved {
+			w.resolveAndStore(ctx)
+		}
+
+		w.refreshMu.Unlock()
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if len(w.endpoints) == 0 && w.err != nil {
+		return nil, w.err
+	}
+
+	return slices.Clone(w.endpoints), nil
+}
+
+// refresh resolves the name once and returns how long to wait before the next refresh.
+func (w *Watcher) refresh(ctx context.Context) time.Duration {
+	w.refreshMu.Lock()
+	defer w.refreshMu.Unlock()
+
+	return w.resolveAndStore(ctx)
+}
+
+// resolveAndStore resolves the name and stores the outcome. Endpoints from earlier
+// lookups are kept when a lookup fails. Callers must hold refreshMu.
+func (w *Watcher) resolveAndStore(ctx context.Context) time.Duration {
+	endpoints, ttl, err := w.resolve(ctx)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.resolved = true
+	w.err = err
+
+	if errors.Is(err, ErrNoEndpoints) {
+		w.endpoints = nil
+	}
+
+	if err != nil {
+		return w.minRefresh
+	}
+
+	w.endpoints = endpoints
+
+	return min(max(ttl, w.minRefresh), w.maxRefresh)
+}
+
+func (w *Watcher) resolve(ctx context.Context) ([]Endpoint, time.Duration, error) {
+	var (
+		endpoints []Endpoint
+		ttl       time.Duration
+		err       error
+	)
+
+	switch w.recordType {
+	case RecordTypeSRV:
+		endpoints, ttl, err = w.resolver.LookupSRV(ctx, w.name)
+	case RecordTypeA:
+		addrs, addrTTL, lookupErr := w.resolver.LookupHosts(ctx, w.name)
+		for _, addr := range addrs {
+			endpoints = append(endpoints, Endpoint{Host: addr.String(), Port: w.port})
+		}
+
+		ttl, err = addrTTL, lookupErr
+	}
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to resolve %s: %w", w.name, err)
+	}
+
+	if len(endpoints) == 0 {
+		if names := w.resolver.names(w.name); len(names) > 1 {
+			return nil, 0, fmt.Errorf("%w: %s (tried %s)", ErrNoEndpoints, w.name, strings.Join(names, ", "))
+		}
+
+		return nil, 0, fmt.Errorf("%w: %s", ErrNoEndpoints, w.name)
+	}
+
+	slices.SortFunc(endpoints, func(a, b Endpoint) int {
+		if a.Host != b.Host {
+			return cmp.Compare(a.Host, b.Host)
+		}
+
+		return a.Port - b.Port
+	})
+
+	return endpoints, ttl, nil
+}
@@ -0,0 +1,51 @@
+package frontend
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ResponseHeader is a response header captured from a sample.
+type ResponseHeader struct {
+	Name  string
+	Value string
+}
+
+// captured returns the response headers the target captures, skipping missing ones.
+// Repeated headers are joined by commas.
+func (t Target) captured(header http.Header) []ResponseHeader {
+	var headers []ResponseHeader
+
+	for _, name := range t.CaptureHeaders {
+		if values := header.Values(name); len(values) > 0 {
+			headers = append(headers, ResponseHeader{Name: name, Value: strings.Join(values, ", ")})
+		}
+	}
+
+	return headers
+}
+
+// capturedMap returns captured response headers by name, or nil when there are none.
+func capturedMap(headers []ResponseHeader) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	captured := make(map[string]string, len(headers))
+	for _, header := range headers {
+		captured[header.Name] = header.Value
+	}
+
+	return captured
+}
+
+// header returns the value of a captured response header, matched case-insensitively.
+func (d InstanceTileData) header(name string) (string, bool) {
+	for _, header := range d.Headers {
+		if strings.EqualFold(header.Name, name) {
+			return header.Value, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,78 @@
+package frontend
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultSkewThreshold = time.Second
+	timestampLayout      = "2006-01-02 15:04:05 MST"
+)
+
+// ClockSkew is an NTP-style estimate of how far the clock of an instance is ahead of ours.
+// The backend stamps its response somewhere between our request start and end, so taking
+// the midpoint as reference bounds the error of Offset by half the round trip.
+type ClockSkew struct {
+	Offset      time.Duration
+	Uncertainty time.Duration
+}
+
+// WithSkewThreshold sets the clock offset beyond which instances are flagged.
+func WithSkewThreshold(threshold time.Duration) HandlerOption {
+	return func(h *FrontendHandler) {
+		if threshold > 0 {
+			h.skewThreshold = threshold
+		}
+	}
+}
+
+// WithLocation sets the time zone timestamps are rendered in.
+func WithLocation(location *time.Location) HandlerOption {
+	return func(h *FrontendHandler) {
+		if location != nil {
+			h.location = location
+		}
+	}
+}
+
+// estimateSkew estimates the clock offset of an instance from the timestamp it reported
+// for a request that started at start and completed at end.
+func estimateSkew(reported, start, end time.Time) ClockSkew {
+	roundTrip := end.Sub(start)
+	midpoint := start.Add(roundTrip / 2) //nolint:mnd // Midpoint of the round trip.
+
+	return ClockSkew{
+		Offset:      reported.Sub(midpoint),
+		Uncertainty: roundTrip / 2, //nolint:mnd // Half the round trip.
+	}
+}
+
+// Exceeds reports whether the offset is beyond threshold even in the most favorable case.
+func (s ClockSkew) Exceeds(threshold time.Duration) bool {
+	return s.Offset.Abs()-s.Uncertainty > threshold
+}
+
+// String formats the offset with its sign and uncertainty, e.g. +1.502s ±3ms.
+func (s ClockSkew) String() string {
+	sign := "+"
+	if s.Offset < 0 {
+		sign = "-"
+	}
+
+	return fmt.Sprintf("%s%v ±%v", sign, s.Offset.Abs().Round(time.Millisecond), s.Uncertainty.Round(time.Millisecond))
+}
+
+// formatRelative describes t relative to now, e.g. "3s ago" or "in 2m0s".
+func formatRelative(t, now time.Time) string {
+	elapsed := now.Sub(t).Round(time.Second)
+
+	switch {
+	case elapsed == 0:
+		return "just now"
+	case elapsed > 0:
+		return elapsed.String() + " ago"
+	default:
+		return "in " + elapsed.Abs().String()
+	}
+}
@@ -0,0 +1,160 @@
+package frontend
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// coalesceSource tells where the tiles of a request came from.
+type coalesceSource string
+
+const (
+	sourceFresh    coalesceSource = "fresh"
+	sourceInFlight coalesceSource = "in_flight"
+	sourceCache    coalesceSource = "cache"
+)
+
+// WithResultCache keeps sampling results for ttl, so identical requests within ttl share them.
+// Concurrent identical requests always share a single sampling run.
+func WithResultCache(ttl time.Duration) HandlerOption {
+	return func(h *FrontendHandler) {
+		if ttl > 0 {
+			h.coalescer.ttl = ttl
+		}
+	}
+}
+
+// sampleRun is a sampling run shared by identical requests. finished is set when the run
+// produced a result; done is closed either way.
+type sampleRun struct {
+	done     chan struct{}
+	data     TilesData
+	at       time.Time
+	finished bool
+}
+
+// coalescer lets identical tile requests share one sampling run and its result for a
+// while afterwards, safe for concurrent use.
+type coalescer struct {
+	ttl time.Duration
+	// keep is how long completed runs are kept, at least ttl. Auto-refresh polls reuse
+	// results younger than the minimum refresh interval even when ttl is shorter.
+	keep time.Duration
+
+	mu   sync.Mutex
+	runs map[string]*sampleRun
+}
+
+// do returns the result of the run for key, starting one with sample when there is
+// neither a run in flight nor a result younger than maxAge. Rate limited results are
+// never shared after their run. Requests waiting for a run that ended without a result,
+// because sample panicked, get ErrSamplingAborted.
+func (c *coalescer) do(
+	ctx context.Context,
+	key string,
+	maxAge time.Duration,
+	sample func() TilesData,
+) (TilesData, time.Time, coalesceSource, error) {
+	c.mu.Lock()
+
+	if c.runs == nil {
+		c.runs = make(map[string]*sampleRun)
+	}
+
+	now := time.Now()
+	c.pruneLocked(now)
+
+	if run, found := c.runs[key]; found && !run.olderThan(now, maxAge) {
+		c.mu.Unlock()
+
+		source := sourceCache
+
+		select {
+		case <-run.done:
+		default:
+			source = sourceInFlight
+
+			select {
+			case <-run.done:
+			case <-ctx.Done():
+				return TilesData{}, time.Time{}, source, fmt.Errorf("failed to wait for shared sampling run: %w", ctx.Err())
+			}
+		}
+
+		if !run.finished {
+			return TilesData{}, time.Time{}, source, ErrSamplingAborted
+		}
+
+		data := run.data
+		data.Instances = slices.Clone(data.Instances)
+
+		return data, run.at, source, nil
+	}
+
+	run := &sampleRun{done: make(chan struct{})}
+	c.runs[key] = run
+	c.mu.Unlock()
+
+	// Waiters are released and unshareable runs forgotten even when sample panics.
+	defer func() {
+		if !run.finished || c.keep <= 0 || run.data.rateLimited {
+			c.mu.Lock()
+			if c.runs[key] == run {
+				delete(c.runs, key)
+			}
+			c.mu.Unlock()
+		}
+
+		close(run.done)
+	}()
+
+	data := sample()
+
+	run.data = data
+	run.data.Instances = slices.Clone(data.Instances)
+	run.at = time.Now()
+	run.finished = true
+
+	return data, run.at, sourceFresh, nil
+}
+
+// pruneLocked removes completed runs older than keep.
+func (c *coalescer) pruneLocked(now time.Time) {
+	for key, run := range c.runs {
+		if run.olderThan(now, c.keep) {
+			delete(c.runs, key)
+		}
+	}
+}
+
+// olderThan reports whether the run completed more than maxAge ago. Runs in flight are
+// never too old to share.
+func (r *sampleRun) olderThan(now time.Time, maxAge time.Duration) bool {
+	select {
+	case <-r.done:
+		return now.Sub(r.at) > maxAge
+	default:
+		return false
+	}
+}
+
+// key identifies requests that produce interchangeable results.
+func (p tilesParams) key(target string) string {
+	return fmt.Sprintf(
+		"%s|%s|%d|%d|%d|%d|%s|%s|%s|%s",
+		target, p.Mode, p.Count, p.Patience, p.Budget, p.Clients, formatSplit(p.ExpectedSplit), p.Timeout, p.Group,
+		headerKey(p.Forwarded),
+	)
+}
+
+// viewKey identifies requests that render the same view: the same population, summary
+// and forwarded headers. How long and how hard sampling tries is not part of the view.
+func (p tilesParams) viewKey(target string) string {
+	return fmt.Sprintf(
+		"%s|%s|%d|%d|%s|%s|%s",
+		target, p.Mode, p.Count, p.Clients, formatSplit(p.ExpectedSplit), p.Group, headerKey(p.Forwarded),
+	)
+}
@@ -0,0 +1,275 @@
+package frontend
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// ColorAttribute names the tile attribute that drives a tile color.
+type ColorAttribute string
+
+const (
+	// ColorByHostname colors tiles per instance.
+	ColorByHostname ColorAttribute = "hostname"
+	// ColorByVersion colors tiles per reported version.
+	ColorByVersion ColorAttribute = "version"
+	// ColorByRevision colors tiles per workload revision parsed from the hostname, and per
+	// version when the hostname has no revision.
+	ColorByRevision ColorAttribute = "revision"
+	// ColorByGoVersion colors tiles per Go runtime version.
+	ColorByGoVersion ColorAttribute = "go_version"
+	// colorByHeaderPrefix colors tiles per value of a captured response header, e.g. header:x-zone.
+	colorByHeaderPrefix = "header:"
+)
+
+// ParseColorAttribute returns the color attribute named by value, or false when it names none.
+func ParseColorAttribute(value string) (ColorAttribute, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	switch attribute := ColorAttribute(value); attribute {
+	case ColorByHostname, ColorByVersion, ColorByRevision, ColorByGoVersion:
+		return attribute, true
+	}
+
+	if name, found := strings.CutPrefix(value, colorByHeaderPrefix); found && name != "" {
+		return ColorAttribute(value), true
+	}
+
+	return "", false
+}
+
+// ColorBy chooses the attributes that drive the two colors of a tile: the hostname color
+// of the left border and the hostname, and the version color of the right border and the version.
+type ColorBy struct {
+	Hostname ColorAttribute
+	Version  ColorAttribute
+}
+
+// orDefault fills unset attributes from fallback.
+func (c ColorBy) orDefault(fallback ColorBy) ColorBy {
+	return ColorBy{
+		Hostname: cmp.Or(c.Hostname, fallback.Hostname),
+		Version:  cmp.Or(c.Version, fallback.Version),
+	}
+}
+
+// WithColorBy sets the attributes that drive the tile colors when a request does not choose
+// them. Tiles are colored by hostname and revision by default.
+func WithColorBy(colorBy ColorBy) HandlerOption {
+	return func(h *FrontendHandler) {
+		h.colorBy = colorBy.orDefault(h.colorBy)
+	}
+}
+
+// WithMarkers adds a shape marker per version color to every tile and legend entry, so
+// tiles do not differ by color only.
+func WithMarkers(markers bool) HandlerOption {
+	return func(h *FrontendHandler) { h.markers = markers }
+}
+
+// WithVersionColors pins the color of versions, e.g. a blue stable and an orange canary.
+// Pinned colors apply whenever a tile color is driven by the version.
+func WithVersionColors(colors map[string]string) HandlerOption {
+	return func(h *FrontendHandler) {
+		h.versionColors = make(map[string]string, len(colors))
+		for version, color := range colors {
+			h.versionColors[colorKey(ColorByVersion, version)] = color
+		}
+	}
+}
+
+// ColorLegend explains the colors of one tile color dimension.
+type ColorLegend struct {
+	// Dimension is the tile color the legend explains: hostname, version, or both when
+	// they are driven by the same attribute.
+	Dimension string
+	Attribute ColorAttribute
+	Entries   []LegendEntry
+}
+
+// LegendEntry is a single attribute value and its color.
+type LegendEntry struct {
+	Value  string
+	Color  string
+	Pinned bool
+	// Marker is the shape of the value when markers are shown.
+	Marker string
+	// key is the palette key of the value.
+	key string
+}
+
+// markerShapes are the shapes of markers, handed out in order and repeated when exhausted.
+var markerShapes = []string{"●", "▲", "■", "◆", "★", "▼", "✚", "⬟", "◐", "✖"}
+
+// colorChoice holds the color choices of a request. Unset choices use the configured ones.
+type colorChoice struct {
+	ColorBy ColorBy
+	// Palette names a built-in palette or tile_colors.
+	Palette string
+	// Markers turns shape markers on or off when set.
+	Markers *bool
+}
+
+// tileColoring colors the tiles of a single view, with a palette per color dimension.
+type tileColoring struct {
+	colorBy  ColorBy
+	hostname *colorPalette
+	version  *colorPalette
+	// markers maps version palette keys to their shape, nil when markers are off.
+	markers map[string]string
+}
+
+// newTileColoring creates the coloring of a view. Color choices of the request override the
+// configured ones.
+func (h *FrontendHandler) newTileColoring(choice colorChoice) *tileColoring {
+	colors := h.paletteColors(cmp.Or(choice.Palette, h.palette))
+
+	coloring := &tileColoring{
+		colorBy:  choice.ColorBy.orDefault(h.colorBy),
+		hostname: newColorPalette(colors, h.colorAssignment, h.versionColors),
+		version:  newColorPalette(colors, h.colorAssignment, h.versionColors),
+	}
+
+	markers := h.markers
+	if choice.Markers != nil {
+		markers = *choice.Markers
+	}
+
+	if markers {
+		coloring.markers = make(map[string]string)
+	}
+
+	return coloring
+}
+
+// reserve assigns the colors of all tiles of a view up front, so they do not depend on the
+// order tiles are colored in.
+func (c *tileColoring) reserve(instances []InstanceTileData) {
+	hostnameKeys := make([]string, 0, len(instances))
+	versionKeys := make([]string, 0, len(instances))
+
+	for _, instance := range instances {
+		hostnameKeys = append(hostnameKeys, c.colorBy.Hostname.key(instance))
+		versionKeys = append(versionKeys, c.colorBy.Version.key(instance))
+	}
+
+	c.hostname.reserve(hostnameKeys)
+	c.version.reserve(versionKeys)
+
+	for _, key := range slices.Compact(slices.Sorted(slices.Values(versionKeys))) {
+		c.marker(key)
+	}
+}
+
+// decorate sets the hostname and version colors of a tile, their text colors for both
+// themes and the marker of the version color.
+func (c *tileColoring) decorate(tile *InstanceTileData) {
+	versionKey := c.colorBy.Version.key(*tile)
+
+	tile.HostnameColor = c.hostname.getColor(c.colorBy.Hostname.key(*tile))
+	tile.Color = c.version.getColor(versionKey)
+	tile.HostnameText = textColors(tile.HostnameColor)
+	tile.VersionText = textColors(tile.Color)
+	tile.Marker = c.marker(versionKey)
+}
+
+// marker returns the shape of a version palette key, or an empty string when markers are off.
+func (c *tileColoring) marker(key string) string {
+	if c.markers == nil {
+		return ""
+	}
+
+	if shape, found := c.markers[key]; found {
+		return shape
+	}
+
+	shape := markerShapes[len(c.markers)%len(markerShapes)]
+	c.markers[key] = shape
+
+	return shape
+}
+
+// legend explains the colors handed out so far. Colors that only stand for the hostname
+// or version they are drawn with explain themselves and get no legend, unless pinned.
+func (c *tileColoring) legend() []ColorLegend {
+	var legends []ColorLegend
+
+	if c.colorBy.Hostname == c.colorBy.Version {
+		if c.version.explains(ColorByVersion) && c.hostname.explains(ColorByHostname) {
+			return nil
+		}
+
+		return append(legends, ColorLegend{
+			Dimension: "hostname and version",
+			Attribute: c.colorBy.Version,
+			Entries:   c.marked(c.version.entries()),
+		})
+	}
+
+	if !c.hostname.explains(ColorByHostname) {
+		legends = append(legends, ColorLegend{
+			Dimension: "hostname",
+			Attribute: c.colorBy.Hostname,
+			Entries:   c.hostname.entries(),
+		})
+	}
+
+	if !c.version.explains(ColorByVersion) {
+		legends = append(legends, ColorLegend{
+			Dimension: "version",
+			Attribute: c.colorBy.Version,
+			Entries:   c.marked(c.version.entries()),
+		})
+	}
+
+	return legends
+}
+
+// marked sets the markers of version legend entries.
+func (c *tileColoring) marked(entries []LegendEntry) []LegendEntry {
+	for i := range entries {
+		entries[i].Marker = c.markers[entries[i].key]
+	}
+
+	return entries
+}
+
+// key returns the palette key of the attribute value of a tile. Keys carry the kind of
+// value, so a revision that falls back to the version shares the pinned version colors.
+func (a ColorAttribute) key(tile InstanceTileData) string {
+	switch a {
+	case ColorByHostname:
+		return colorKey(a, tile.Info.Hostname)
+	case ColorByGoVersion:
+		return colorKey(a, tile.Info.GoVersion)
+	case ColorByRevision:
+		if revision := tile.Pod.RevisionKey(); revision != "" {
+			return colorKey(a, revision)
+		}
+	case ColorByVersion:
+	default:
+		if name, found := strings.CutPrefix(string(a), colorByHeaderPrefix); found {
+			value, captured := tile.header(name)
+			if !captured {
+				value = missingGroupValue
+			}
+
+			return colorKey(ColorAttribute(colorByHeaderPrefix), value)
+		}
+	}
+
+	return colorKey(ColorByVersion, tile.Info.Version)
+}
+
+// colorKey builds a palette key from the kind of a value and the value.
+func colorKey(attribute ColorAttribute, value string) string {
+	return string(attribute) + "|" + value
+}
+
+// colorValue returns the value of a palette key.
+func colorValue(key string) string {
+	_, value, _ := strings.Cut(key, "|")
+
+	return value
+}
@@ -0,0 +1,137 @@
+package frontend
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// lightTileBackground and darkTileBackground are the tile backgrounds of the light and
+	// dark themes, --card-bg in index.gohtml.
+	lightTileBackground = "#ffffff"
+	darkTileBackground  = "#292a2d"
+	// minTextContrast is the WCAG AA contrast ratio of normal text.
+	minTextContrast = 4.5
+	// contrastStep is how far a text color moves towards black or white per adjustment.
+	contrastStep = 0.05
+
+	// midLuminance is the background luminance above which black text contrasts more than white.
+	midLuminance      = 0.179
+	luminanceOffset   = 0.05
+	redLuminance      = 0.2126
+	greenLuminance    = 0.7152
+	blueLuminance     = 0.0722
+	srgbEncodedLimit  = 0.04045
+	hexColorLength    = 7
+	hexChannelDigits  = 2
+	hexChannelBitSize = 8
+)
+
+// ThemedColor is a text color for each theme.
+type ThemedColor struct {
+	Light string
+	Dark  string
+}
+
+// rgb is an sRGB color with channels between 0 and 1.
+type rgb [3]float64
+
+// textColors returns color adjusted to a readable contrast against the tile backgrounds
+// of both themes. Colors that are not #rrggbb hex colors are returned unchanged.
+func textColors(color string) ThemedColor {
+	return ThemedColor{
+		Light: readableOn(color, lightTileBackground),
+		Dark:  readableOn(color, darkTileBackground),
+	}
+}
+
+// readableOn moves color towards black on light backgrounds and towards white on dark
+// ones until its contrast against background reaches minTextContrast.
+func readableOn(color, background string) string {
+	foreground, ok := parseHexColor(color)
+	if !ok {
+		return color
+	}
+
+	back, _ := parseHexColor(background)
+
+	target := rgb{1, 1, 1}
+	if back.luminance() > midLuminance {
+		target = rgb{}
+	}
+
+	adjusted := foreground
+	for amount := contrastStep; amount <= 1 && contrastRatio(adjusted, back) < minTextContrast; amount += contrastStep {
+		adjusted = foreground.mix(target, amount)
+	}
+
+	if adjusted == foreground {
+		return color
+	}
+
+	return adjusted.hex()
+}
+
+// contrastRatio is the WCAG contrast ratio of two colors, between 1 and 21.
+func contrastRatio(a, b rgb) float64 {
+	lighter, darker := a.luminance(), b.luminance()
+	if darker > lighter {
+		lighter, darker = darker, lighter
+	}
+
+	return (lighter + luminanceOffset) / (darker + luminanceOffset)
+}
+
+// parseHexColor parses a #rrggbb color.
+func parseHexColor(color string) (rgb, bool) {
+	var parsed rgb
+
+	if len(color) != hexColorLength || !strings.HasPrefix(color, "#") {
+		return parsed, false
+	}
+
+	for i := range parsed {
+		start := 1 + i*hexChannelDigits
+
+		channel, err := strconv.ParseUint(color[start:start+hexChannelDigits], 16, hexChannelBitSize)
+		if err != nil {
+			return parsed, false
+		}
+
+		parsed[i] = float64(channel) / maxChannel
+	}
+
+	return parsed, true
+}
+
+// luminance is the WCAG relative luminance of the color.
+func (c rgb) luminance() float64 {
+	var linear rgb
+
+	for i, channel := range c {
+		linear[i] = channel / srgbLinearSlope
+		if channel > srgbEncodedLimit {
+			linear[i] = math.Pow((channel+srgbOffset)/(1+srgbOffset), srgbGamma)
+		}
+	}
+
+	return redLuminance*linear[0] + greenLuminance*linear[1] + blueLuminance*linear[2]
+}
+
+// mix moves the color by amount towards target.
+func (c rgb) mix(target rgb, amount float64) rgb {
+	var mixed rgb
+	for i := range c {
+		mixed[i] = c[i] + (target[i]-c[i])*amount
+	}
+
+	return mixed
+}
+
+// hex renders the color as #rrggbb.
+func (c rgb) hex() string {
+	return fmt.Sprintf("#%02x%02x%02x",
+		int(math.Round(c[0]*maxChannel)), int(math.Round(c[1]*maxChannel)), int(math.Round(c[2]*maxChannel)))
+}
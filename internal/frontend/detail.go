@@ -0,0 +1,238 @@
+package frontend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"phasor-frontend/internal/inventory"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// Latency percentiles shown on the instance detail page.
+	medianPercentile = 50
+	p90Percentile    = 90
+	p99Percentile    = 99
+	// instanceDetailTemplate renders the instance detail without the page around it, for the drawer.
+	instanceDetailTemplate = "instance-detail"
+)
+
+// InstanceDetailData contains data for rendering the history of a single instance.
+type InstanceDetailData struct {
+	Hostname string
+	// Versions are the inventory entries of the hostname, one per target and version seen.
+	Versions []inventory.Entry
+	// Samples are the recent samples of the hostname, newest first.
+	Samples []inventory.Sample
+	// Latency holds the latency percentiles of the successful samples, when there are any.
+	Latency *LatencyPercentiles
+	Errors  int
+	// ClockOffset is the clock offset estimated from the last successful sample.
+	ClockOffset time.Duration
+	// Headers are the captured response headers of the last successful sample.
+	Headers []ResponseHeader
+	// HuntTarget names the target that can be asked to route to the hostname, and is empty
+	// when no target of the hostname pins hosts.
+	HuntTarget string
+	// Hunt is the outcome of the last hunt for the hostname.
+	Hunt *HuntResult
+}
+
+// LatencyPercentiles summarizes the latencies of the samples of an instance.
+type LatencyPercentiles struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+}
+
+// HuntResult is the outcome of sampling a target until a hostname answered.
+type HuntResult struct {
+	Target  string
+	Samples int
+	Found   bool
+	// Stopped tells why the hunt gave up, when the hostname did not answer.
+	Stopped string
+}
+
+// InstanceDetailHandler serves the sample history of the instance named by the hostname
+// path parameter. htmx requests get the detail without the page around it, for the drawer.
+func (h *FrontendHandler) InstanceDetailHandler(writer http.ResponseWriter, req *http.Request) {
+	hostname := req.PathValue("hostname")
+
+	data := h.instanceDetail(hostname)
+	if len(data.Samples) == 0 && len(data.Versions) == 0 {
+		http.Error(writer, fmt.Sprintf("instance %s has not been sampled", hostname), http.StatusNotFound)
+
+		return
+	}
+
+	h.renderInstanceDetail(writer, req, data)
+}
+
+// InstanceHuntHandler samples a target until the instance named by the hostname path
+// parameter answers, asking the routing layer to route to it by the pin header of the
+// target. It gives up after budget samples or when the time budget runs out, and renders
+// the instance detail with the outcome.
+func (h *FrontendHandler) InstanceHuntHandler(writer http.ResponseWriter, req *http.Request) {
+	hostname := req.PathValue("hostname")
+	query := req.URL.Query()
+	target := h.selectTarget(query.Get("target"))
+
+	if !target.pinsHosts() {
+		http.Error(writer, fmt.Sprintf("target %s does not pin hosts", target.Name), http.StatusBadRequest)
+
+		return
+	}
+
+	if limited, retryAfter := h.clientLimited(req); limited {
+		h.metrics.rateLimited.With(limitClient).Inc()
+		rejectLimited(writer, limitClient, retryAfter)
+
+		return
+	}
+
+	forwarded := target.forwarded(req, false)
+	forwarded.Set(target.PinHeader, hostname)
+
+	ctx, cancel := context.WithTimeout(req.Context(), h.requestBudget)
+	defer cancel()
+
+	hunt := h.hunt(withForwarded(ctx, forwarded), target, hostname,
+		parseBoundedInt(query.Get("budget"), defaultBudget, maxBudget))
+
+	data := h.instanceDetail(hostname)
+	data.Hunt = &hunt
+
+	h.renderInstanceDetail(writer, req, data)
+}
+
+// hunt samples target until hostname answers or budget samples were taken, and records
+// the samples in the inventory.
+func (h *FrontendHandler) hunt(ctx context.Context, target Target, hostname string, budget int) HuntResult {
+	result := HuntResult{Target: target.Name, Stopped: fmt.Sprintf("sample budget of %d exhausted", budget)}
+	instances := make([]InstanceTileData, 0, budget)
+
+	for range budget {
+		instance := h.sampleOnce(ctx, target)
+		if instance.RateLimited {
+			result.Stopped = "global rate limit reached"
+
+			break
+		}
+
+		if instance.NotSampled {
+			result.Stopped = "time budget exhausted"
+
+			break
+		}
+
+		instances = append(instances, instance)
+
+		if instance.Reachable && instance.Info.Hostname == hostname {
+			result.Found = true
+			result.Stopped = ""
+
+			break
+		}
+	}
+
+	result.Samples = len(instances)
+	h.recordInventory(target, instances)
+
+	return result
+}
+
+// pinsHosts reports whether requests to the target can ask for a specific host.
+func (t Target) pinsHosts() bool {
+	return t.PinHeader != "" && t.Pods == nil
+}
+
+// instanceDetail collects the inventory entries and sample history of a hostname.
+func (h *FrontendHandler) instanceDetail(hostname string) InstanceDetailData {
+	data := InstanceDetailData{Hostname: hostname}
+
+	if h.inventory == nil {
+		return data
+	}
+
+	for _, entry := range h.inventory.Entries(time.Now()) {
+		if entry.Hostname == hostname {
+			data.Versions = append(data.Versions, entry)
+		}
+	}
+
+	data.Samples = h.inventory.History(hostname)
+	slices.Reverse(data.Samples)
+
+	var latencies []time.Duration
+
+	for _, sample := range data.Samples {
+		if sample.Failed {
+			data.Errors++
+
+			continue
+		}
+
+		if latencies == nil {
+			data.ClockOffset = sample.ClockOffset
+			data.Headers = sortedHeaders(sample.Headers)
+		}
+
+		latencies = append(latencies, sample.Latency)
+	}
+
+	if len(latencies) > 0 {
+		slices.Sort(latencies)
+		data.Latency = &LatencyPercentiles{
+			P50: percentile(latencies, medianPercentile),
+			P90: percentile(latencies, p90Percentile),
+			P99: percentile(latencies, p99Percentile),
+		}
+	}
+
+	if len(data.Samples) > 0 && h.selectTarget(data.Samples[0].Target).pinsHosts() {
+		data.HuntTarget = data.Samples[0].Target
+	}
+
+	return data
+}
+
+// renderInstanceDetail renders the instance detail, as a fragment for htmx requests.
+func (h *FrontendHandler) renderInstanceDetail(writer http.ResponseWriter, req *http.Request, data InstanceDetailData) {
+	name := "instance.gohtml"
+	if req.Header.Get(htmxRequestHeader) == "true" {
+		name = instanceDetailTemplate
+	}
+
+	err := h.templates.ExecuteTemplate(writer, name, data)
+	if err != nil {
+		http.Error(
+			writer,
+			fmt.Sprintf("failed to render instance: %v", err),
+			http.StatusInternalServerError,
+		)
+
+		return
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + percent - 1) / percent
+
+	return sorted[max(rank, 1)-1]
+}
+
+// sortedHeaders returns captured headers ordered by name.
+func sortedHeaders(headers map[string]string) []ResponseHeader {
+	sorted := make([]ResponseHeader, 0, len(headers))
+	for name, value := range headers {
+		sorted = append(sorted, ResponseHeader{Name: name, Value: value})
+	}
+
+	slices.SortFunc(sorted, func(a, b ResponseHeader) int { return strings.Compare(a.Name, b.Name) })
+
+	return sorted
+}
@@ -0,0 +1,55 @@
+package frontend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"phasor-frontend/internal/inventory"
+)
+
+// EventsData contains data for rendering the event feed.
+type EventsData struct {
+	Events []inventory.Event `json:"events"`
+}
+
+// EventsHandler serves the restart and crash loop event feed as an HTML page.
+func (h *FrontendHandler) EventsHandler(writer http.ResponseWriter, _ *http.Request) {
+	err := h.templates.ExecuteTemplate(writer, "events.gohtml", h.eventsData())
+	if err != nil {
+		http.Error(
+			writer,
+			fmt.Sprintf("failed to render events: %v", err),
+			http.StatusInternalServerError,
+		)
+
+		return
+	}
+}
+
+// EventsJSONHandler serves the restart and crash loop event feed as JSON.
+func (h *FrontendHandler) EventsJSONHandler(writer http.ResponseWriter, _ *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+
+	err := json.NewEncoder(writer).Encode(h.eventsData())
+	if err != nil {
+		http.Error(
+			writer,
+			fmt.Sprintf("failed to encode events: %v", err),
+			http.StatusInternalServerError,
+		)
+
+		return
+	}
+}
+
+func (h *FrontendHandler) eventsData() EventsData {
+	data := EventsData{
+		Events: []inventory.Event{},
+	}
+
+	if h.inventory != nil {
+		data.Events = h.inventory.Events()
+	}
+
+	return data
+}
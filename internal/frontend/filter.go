@@ -0,0 +1,237 @@
+package frontend
+
+import (
+	"cmp"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// filterOnlyErrors shows only the tiles of failed samples.
+	filterOnlyErrors = "errors"
+	// filterOnlyOutdated shows only the instances that do not run the newest version.
+	filterOnlyOutdated = "outdated"
+)
+
+// tileFilter narrows the rendered tiles. The summary and the colors always cover all samples.
+type tileFilter struct {
+	// Versions keeps only these versions, ExcludeVersions hides these versions.
+	Versions        []string
+	ExcludeVersions []string
+	// Hostname is a glob, or a regular expression when wrapped in slashes, e.g. /^web-\d+$/.
+	Hostname string
+	// Only is errors or outdated.
+	Only string
+
+	hostnameRegexp *regexp.Regexp
+}
+
+// FilterSummary describes the filter of the rendered tiles.
+type FilterSummary struct {
+	Description string
+	Shown       int
+	Total       int
+}
+
+// parseTileFilter reads the filter from the query. Invalid hostname patterns and unknown
+// values of only are ignored.
+func parseTileFilter(query url.Values) tileFilter {
+	filter := tileFilter{
+		Versions:        parseList(query.Get("version")),
+		ExcludeVersions: parseList(query.Get("exclude_version")),
+	}
+
+	switch query.Get("only") {
+	case filterOnlyErrors, filterOnlyOutdated:
+		filter.Only = query.Get("only")
+	}
+
+	hostname := strings.TrimSpace(query.Get("hostname"))
+
+	if len(hostname) > 1 && strings.HasPrefix(hostname, "/") && strings.HasSuffix(hostname, "/") {
+		pattern, err := regexp.Compile(hostname[1 : len(hostname)-1])
+		if err == nil {
+			filter.Hostname = hostname
+			filter.hostnameRegexp = pattern
+		}
+
+		return filter
+	}
+
+	_, err := path.Match(hostname, "")
+	if err == nil {
+		filter.Hostname = hostname
+	}
+
+	return filter
+}
+
+// parseList parses a comma-separated list, skipping empty entries.
+func parseList(value string) []string {
+	var entries []string
+
+	for entry := range strings.SplitSeq(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// active reports whether the filter hides any tiles.
+func (f tileFilter) active() bool {
+	return len(f.Versions) > 0 || len(f.ExcludeVersions) > 0 || f.Hostname != "" || f.Only != ""
+}
+
+// setValues adds the filter to the query of a permalink.
+func (f tileFilter) setValues(values url.Values) {
+	if len(f.Versions) > 0 {
+		values.Set("version", strings.Join(f.Versions, ","))
+	}
+
+	if len(f.ExcludeVersions) > 0 {
+		values.Set("exclude_version", strings.Join(f.ExcludeVersions, ","))
+	}
+
+	if f.Hostname != "" {
+		values.Set("hostname", f.Hostname)
+	}
+
+	if f.Only != "" {
+		values.Set("only", f.Only)
+	}
+}
+
+// String describes the filter, e.g. version 1.2.0, hostname web-*, only outdated.
+func (f tileFilter) String() string {
+	var parts []string
+
+	if len(f.Versions) > 0 {
+		parts = append(parts, "version "+strings.Join(f.Versions, " or "))
+	}
+
+	if len(f.ExcludeVersions) > 0 {
+		parts = append(parts, "not version "+strings.Join(f.ExcludeVersions, " or "))
+	}
+
+	if f.Hostname != "" {
+		parts = append(parts, "hostname "+f.Hostname)
+	}
+
+	if f.Only != "" {
+		parts = append(parts, "only "+f.Only)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// matches reports whether the filter keeps the tile. newest is the newest version of the
+// view; the outdated filter keeps every successful sample when it is empty.
+func (f tileFilter) matches(tile InstanceTileData, newest string) bool {
+	failed := !tile.Reachable && !tile.NotSampled
+
+	switch {
+	case f.Only == filterOnlyErrors && !failed:
+		return false
+	case f.Only == filterOnlyOutdated && (!tile.Reachable || tile.Info.Version == newest):
+		return false
+	case len(f.Versions) > 0 && !slices.Contains(f.Versions, tile.Info.Version):
+		return false
+	case slices.Contains(f.ExcludeVersions, tile.Info.Version):
+		return false
+	}
+
+	return f.matchesHostname(tile.Info.Hostname)
+}
+
+// matchesHostname reports whether the hostname matches the glob or regular expression.
+func (f tileFilter) matchesHostname(hostname string) bool {
+	switch {
+	case f.hostnameRegexp != nil:
+		return f.hostnameRegexp.MatchString(hostname)
+	case f.Hostname != "":
+		matched, _ := path.Match(f.Hostname, hostname)
+
+		return matched
+	default:
+		return true
+	}
+}
+
+// apply returns the tiles the filter keeps and describes the filter, or returns the
+// tiles unchanged and nil when the filter is not active.
+func (f tileFilter) apply(instances []InstanceTileData) ([]InstanceTileData, *FilterSummary) {
+	if !f.active() {
+		return instances, nil
+	}
+
+	newest := newestVersion(instances)
+	kept := make([]InstanceTileData, 0, len(instances))
+
+	for _, instance := range instances {
+		if f.matches(instance, newest) {
+			kept = append(kept, instance)
+		}
+	}
+
+	return kept, &FilterSummary{Description: f.String(), Shown: len(kept), Total: len(instances)}
+}
+
+// newestVersion returns the highest version among the successful samples.
+func newestVersion(instances []InstanceTileData) string {
+	var newest string
+
+	for _, instance := range instances {
+		if instance.Reachable && (newest == "" || compareVersions(instance.Info.Version, newest) > 0) {
+			newest = instance.Info.Version
+		}
+	}
+
+	return newest
+}
+
+// compareVersions orders versions like 1.2.0, v1.10.0 and 1.3.0-rc.1: dot-separated
+// fields compare numerically where both are numbers, and a pre-release orders before
+// its release.
+func compareVersions(a, b string) int {
+	coreA, preA, hasPreA := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	coreB, preB, hasPreB := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+
+	result := compareFields(strings.Split(coreA, "."), strings.Split(coreB, "."))
+	if result != 0 {
+		return result
+	}
+
+	switch {
+	case hasPreA && !hasPreB:
+		return -1
+	case !hasPreA && hasPreB:
+		return 1
+	default:
+		return compareFields(strings.Split(preA, "."), strings.Split(preB, "."))
+	}
+}
+
+// compareFields compares version fields pairwise; missing fields order first.
+func compareFields(a, b []string) int {
+	for i := range min(len(a), len(b)) {
+		numberA, errA := strconv.Atoi(a[i])
+		numberB, errB := strconv.Atoi(b[i])
+
+		result := cmp.Compare(a[i], b[i])
+		if errA == nil && errB == nil {
+			result = cmp.Compare(numberA, numberB)
+		}
+
+		if result != 0 {
+			return result
+		}
+	}
+
+	return cmp.Compare(len(a), len(b))
+}
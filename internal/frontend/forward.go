@@ -0,0 +1,108 @@
+package frontend
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// CanaryRoute tells how a request is routed to the canary of a target, by a header or
+// a cookie with the given value. Canary routing is not configured when it is zero.
+type CanaryRoute struct {
+	Header string
+	Cookie string
+	Value  string
+}
+
+// Enabled reports whether canary routing is configured.
+func (c CanaryRoute) Enabled() bool {
+	return (c.Header != "" || c.Cookie != "") && c.Value != ""
+}
+
+// String describes how requests are routed to the canary.
+func (c CanaryRoute) String() string {
+	if c.Header != "" {
+		return fmt.Sprintf("header %s: %s", c.Header, c.Value)
+	}
+
+	return fmt.Sprintf("cookie %s=%s", c.Cookie, c.Value)
+}
+
+type forwardedKey struct{}
+
+// forwarded returns the headers of the browser request that are forwarded to the target,
+// with allowlisted cookies merged into a single Cookie header. When canary is set, the
+// canary route of the target is added.
+func (t Target) forwarded(req *http.Request, canary bool) http.Header {
+	header := make(http.Header)
+
+	for _, name := range t.ForwardHeaders {
+		if values := req.Header.Values(name); len(values) > 0 {
+			header[http.CanonicalHeaderKey(name)] = slices.Clone(values)
+		}
+	}
+
+	var cookies []string
+
+	for _, name := range t.ForwardCookies {
+		if cookie, err := req.Cookie(name); err == nil {
+			cookies = append(cookies, (&http.Cookie{Name: cookie.Name, Value: cookie.Value}).String())
+		}
+	}
+
+	if canary && t.Canary.Enabled() {
+		if t.Canary.Header != "" {
+			header.Set(t.Canary.Header, t.Canary.Value)
+		} else {
+			cookies = append(cookies, (&http.Cookie{Name: t.Canary.Cookie, Value: t.Canary.Value}).String())
+		}
+	}
+
+	if len(cookies) > 0 {
+		header.Set("Cookie", strings.Join(cookies, "; "))
+	}
+
+	return header
+}
+
+// routing describes how the samples of a request were routed, or returns an empty string
+// when the request did not ask for the canary.
+func (t Target) routing(canary bool) string {
+	if !canary {
+		return ""
+	}
+
+	if !t.Canary.Enabled() {
+		return fmt.Sprintf("canary routing is not configured for target %s", t.Name)
+	}
+
+	return "routed to canary by " + t.Canary.String()
+}
+
+// withForwarded makes the forwarded headers available to the instance info requests of ctx.
+func withForwarded(ctx context.Context, header http.Header) context.Context {
+	return context.WithValue(ctx, forwardedKey{}, header)
+}
+
+// setHeaders adds the static headers of the target and the forwarded headers of ctx to req.
+func (t Target) setHeaders(ctx context.Context, req *http.Request) {
+	for name, values := range t.Headers {
+		req.Header[name] = slices.Clone(values)
+	}
+
+	forwarded, _ := ctx.Value(forwardedKey{}).(http.Header)
+	maps.Copy(req.Header, forwarded)
+}
+
+// headerKey renders a header in a stable order, for coalescing keys.
+func headerKey(header http.Header) string {
+	entries := make([]string, 0, len(header))
+	for _, name := range slices.Sorted(maps.Keys(header)) {
+		entries = append(entries, name+"="+strings.Join(header[name], ","))
+	}
+
+	return strings.Join(entries, ";")
+}
@@ -1,21 +1,26 @@
 package frontend
 
 import (
-	"cmp"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"hash/fnv"
 	"html/template"
+	"io"
 	"net/http"
+	"net/url"
 	"path/filepath"
+	"phasor-frontend/internal/inventory"
+	"phasor-frontend/internal/metrics"
+	"regexp"
 	"slices"
 	"strconv"
 	"time"
 )
 
 const (
+	defaultRequestBudget     = 10 * time.Second
 	defaultTileCount         = 3
 	maxTileCount             = 20
 	httpClientTimeout        = 5 * time.Second
@@ -25,8 +30,14 @@ const (
 	transportMaxIdlePerHost  = 2
 )
 
-// ErrUnexpectedStatusCode is returned when the instance API returns a non-200 status code.
-var ErrUnexpectedStatusCode = errors.New("unexpected status code from instance API")
+var (
+	// ErrUnexpectedStatusCode is returned when the instance API returns a non-200 status code.
+	ErrUnexpectedStatusCode = errors.New("unexpected status code from instance API")
+	// ErrNoTargets is returned when the frontend handler is created without targets.
+	ErrNoTargets = errors.New("at least one target is required")
+	// ErrSamplingAborted is returned to requests sharing a sampling run that ended without a result.
+	ErrSamplingAborted = errors.New("shared sampling run ended without a result")
+)
 
 // InstanceInfoResponse represents the response from the backend instance API.
 type InstanceInfoResponse struct {
@@ -50,8 +61,47 @@ type InstanceInfoResponse struct {
 type FrontendHandler struct {
 	templates      *template.Template
 	instanceClient *http.Client
-	instanceURL    string
+	targets        []Target
 	tileColors     []string
+	inventory      *inventory.Store
+	skewThreshold  time.Duration
+	location       *time.Location
+	limits         Limits
+	sampleSlots    chan struct{}
+	registry       *metrics.Registry
+	metrics        *handlerMetrics
+	cache          tilesCache
+	coalescer      coalescer
+	requestBudget  time.Duration
+	// hostnamePatterns split hostnames into pod names.
+	hostnamePatterns []*regexp.Regexp
+	colorAssignment  ColorAssignment
+	colorBy          ColorBy
+	// palette names the palette tiles are colored with, the configured tile colors by default.
+	palette string
+	markers bool
+	// minRefresh is the shortest auto-refresh interval clients may use.
+	minRefresh time.Duration
+	// versionColors maps version palette keys to pinned colors.
+	versionColors map[string]string
+}
+
+// HandlerOption configures optional collaborators of a FrontendHandler.
+type HandlerOption func(*FrontendHandler)
+
+// WithRequestBudget bounds the time a tile request spends sampling. Samples that have
+// not finished when the budget runs out are shown as not sampled.
+func WithRequestBudget(budget time.Duration) HandlerOption {
+	return func(h *FrontendHandler) {
+		if budget > 0 {
+			h.requestBudget = budget
+		}
+	}
+}
+
+// WithInventory records every sample in the given instance inventory.
+func WithInventory(store *inventory.Store) HandlerOption {
+	return func(h *FrontendHandler) { h.inventory = store }
 }
 
 // InstanceTileData represents data for a single instance tile in the UI.
@@ -60,36 +110,74 @@ type InstanceTileData struct {
 	Info          InstanceInfoResponse
 	Color         string
 	HostnameColor string
+	// HostnameText and VersionText are the hostname and version colors adjusted to a readable
+	// contrast against the tile background of each theme.
+	HostnameText ThemedColor
+	VersionText  ThemedColor
+	// Marker is the shape of the version color when markers are shown.
+	Marker    string
+	Endpoint  string // Pod address when the pod was sampled directly
+	Reachable bool
+	// Restarted is set when the uptime of the instance went backwards since its previous sample.
+	Restarted bool
+	// Restarts is the number of restarts of the instance within the restart window.
+	Restarts int
+	// CrashLooping is set when the instance restarted too often within the restart window.
+	CrashLooping bool
+	// Retries and Hedges count the extra requests made for this sample. They are not samples themselves.
+	Retries int
+	Hedges  int
+	// ShortCircuited is set when the circuit breaker of the target rejected the sample.
+	ShortCircuited bool
+	// RateLimited is set when the global sample rate limit prevented the sample.
+	RateLimited bool
+	// Pod is the hostname split into workload, revision and pod suffix, when it matches a pattern.
+	Pod PodName
+	// Client is the simulated client that took the sample in sticky sampling, starting at 1.
+	Client int
+	// Headers are the captured response headers of the sample, in the order of the target.
+	Headers []ResponseHeader
+	// NotSampled is set when the time budget of the request ran out before the sample finished.
+	NotSampled bool
+	// Latency is the duration of the answering request.
+	Latency time.Duration
+	// Skew is the estimated clock offset of the instance, Skewed is set when it exceeds the threshold.
+	Skew   ClockSkew
+	Skewed bool
+	// LocalTimestamp and RelativeTimestamp render Info.Timestamp in the configured time zone
+	// and relative to the time the tiles were rendered.
+	LocalTimestamp    string
+	RelativeTimestamp string
 }
 
 // TilesData holds the collection of instance tiles to render.
 type TilesData struct {
+	Target    string
+	Summary   *Summary
 	Instances []InstanceTileData
-}
-
-// colorPalette holds a list of colors for deterministic assignment.
-type colorPalette struct {
-	colors []string
-}
-
-// newColorPalette creates a new color palette with the given colors.
-func newColorPalette(colors []string) *colorPalette {
-	return &colorPalette{colors: colors}
-}
-
-// getColor returns a deterministic color for the given key using hash-based assignment.
-// The same key always returns the same color across requests.
-func (cp *colorPalette) getColor(key string) string {
-	h := fnv.New32a()
-	_, _ = h.Write([]byte(key))
-	idx := int(h.Sum32()) % len(cp.colors)
-
-	return cp.colors[idx]
-}
-
-// IndexData contains data for rendering the index page.
-type IndexData struct {
-	Count int
+	Error     string
+	// Degraded explains why cached tiles are shown instead of fresh samples.
+	Degraded string
+	// SampledAt is when the tiles were sampled. Shared is set when they were sampled for
+	// another request, and Freshness describes both.
+	SampledAt time.Time
+	Shared    bool
+	Freshness string
+	// Routing describes how samples were routed when the canary was requested.
+	Routing string
+	// Refresh makes the tiles poll for fresh samples when auto-refresh is on.
+	Refresh *AutoRefresh
+	// Legend explains the tile colors that are not self-explanatory.
+	Legend []ColorLegend
+	// Filter describes the filter of the rendered tiles, when the request has one.
+	Filter *FilterSummary
+	// colors, filter and order hold the color choices, the tile filter and the tile order
+	// of the request.
+	colors colorChoice
+	filter tileFilter
+	order  tileOrder
+	// rateLimited is set when the global sample rate limit cut sampling short.
+	rateLimited bool
 }
 
 // errorInstanceInfo returns an InstanceInfoResponse for error cases.
@@ -103,40 +191,69 @@ func errorInstanceInfo() InstanceInfoResponse {
 	}
 }
 
+// templateFuncs are the functions available to the templates, in addition to the builtins.
+var templateFuncs = template.FuncMap{
+	// pathescape escapes a value for a URL path segment, where urlquery would turn spaces into "+".
+	"pathescape": url.PathEscape,
+}
+
 // NewFrontendHandler creates a new frontend handler with the specified templates path,
-// instance API URL, and tile colors.
+// targets, and tile colors. The first target is sampled when no target is requested.
 func NewFrontendHandler(
-	templatesPath, instanceURL string,
+	templatesPath string,
+	targets []Target,
 	tileColors []string,
+	opts ...HandlerOption,
 ) (*FrontendHandler, error) {
-	tmpl, err := template.ParseGlob(filepath.Join(templatesPath, "*.gohtml"))
+	if len(targets) == 0 {
+		return nil, ErrNoTargets
+	}
+
+	tmpl, err := template.New("").Funcs(templateFuncs).ParseGlob(filepath.Join(templatesPath, "*.gohtml"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse templates: %w", err)
 	}
 
-	return &FrontendHandler{
-		templates: tmpl,
-		instanceClient: &http.Client{
-			Timeout: httpClientTimeout,
-			Transport: &http.Transport{
-				MaxIdleConns:        transportMaxIdleConns,
-				IdleConnTimeout:     transportIdleConnTimeout,
-				DisableCompression:  false,
-				DisableKeepAlives:   false,
-				MaxIdleConnsPerHost: transportMaxIdlePerHost,
-			},
-		},
-		instanceURL: instanceURL,
-		tileColors:  tileColors,
-	}, nil
-}
+	handler := &FrontendHandler{
+		templates:        tmpl,
+		instanceClient:   newInstanceClient(nil),
+		targets:          slices.Clone(targets),
+		tileColors:       tileColors,
+		skewThreshold:    defaultSkewThreshold,
+		requestBudget:    defaultRequestBudget,
+		location:         time.Local,
+		hostnamePatterns: []*regexp.Regexp{kubernetesPodName},
+		colorAssignment:  ColorAssignmentDistinct,
+		colorBy:          ColorBy{Hostname: ColorByHostname, Version: ColorByRevision},
+		minRefresh:       defaultMinRefresh,
+	}
 
-// IndexHandler serves the main index page with the default tile count.
-func (h *FrontendHandler) IndexHandler(writer http.ResponseWriter, _ *http.Request) {
-	data := IndexData{
-		Count: defaultTileCount,
+	for i, target := range handler.targets {
+		if target.TLS != nil {
+			handler.targets[i].client = newInstanceClient(target.TLS)
+		}
+	}
+
+	for _, opt := range opts {
+		opt(handler)
+	}
+
+	handler.coalescer.keep = max(handler.coalescer.ttl, handler.minRefresh)
+
+	if handler.registry == nil {
+		handler.registry = metrics.NewRegistry()
 	}
 
+	handler.metrics = newHandlerMetrics(handler.registry, handler.limits)
+
+	return handler, nil
+}
+
+// IndexHandler serves the main index page. It accepts the parameters of the tiles endpoint,
+// so a permalink restores the controls and tiles of a view.
+func (h *FrontendHandler) IndexHandler(writer http.ResponseWriter, req *http.Request) {
+	data := h.indexData(parseTilesParams(req.URL.Query()))
+
 	err := h.templates.ExecuteTemplate(writer, "index.gohtml", data)
 	if err != nil {
 		http.Error(
@@ -149,54 +266,87 @@ func (h *FrontendHandler) IndexHandler(writer http.ResponseWriter, _ *http.Reque
 	}
 }
 
-// TilesHandler renders instance tiles based on the count query parameter.
+// TilesHandler renders instance tiles based on the count, target and mode query parameters.
+// Targets with pod discovery render one tile per pod and ignore count. Requests over
+// the rate limits are answered from cached tiles or rejected. With stream=true, tiles
+// are streamed as their samples complete. Auto-refresh polls reuse the result of an
+// identical request younger than the minimum refresh interval.
 func (h *FrontendHandler) TilesHandler(writer http.ResponseWriter, req *http.Request) {
-	countStr := req.URL.Query().Get("count")
-	count := defaultTileCount
+	params := parseTilesParams(req.URL.Query())
+	target := h.selectTarget(params.Target)
+	params.Forwarded = target.forwarded(req, params.Canary)
 
-	if countStr != "" {
-		parsedCount, err := strconv.Atoi(countStr)
-		if err == nil && parsedCount > 0 && parsedCount <= maxTileCount {
-			count = parsedCount
-		}
+	pushPermalink(writer, req, params)
+
+	if limited, retryAfter := h.clientLimited(req); limited {
+		h.limitExceeded(writer, params, target.Name, limitClient, retryAfter)
+
+		return
 	}
 
-	palette := newColorPalette(h.tileColors)
+	// Auto-refresh polls are not streamed, so they share results and never sample the
+	// backend more often than the minimum refresh interval.
+	maxAge := h.coalescer.ttl
+	if params.Refresh > 0 {
+		maxAge = max(maxAge, h.minRefresh)
+	} else if params.Stream {
+		h.streamTiles(writer, req, params)
 
-	instances := make([]InstanceTileData, count)
-	for i := range count {
-		info, err := h.fetchInstanceInfo(req.Context())
-		if err != nil {
-			info = errorInstanceInfo()
-		}
+		return
+	}
 
-		tileColor := palette.getColor(info.Hostname + "|" + info.Version)
-		instances[i] = InstanceTileData{
-			Index:         i + 1,
-			Info:          info,
-			Color:         tileColor,
-			HostnameColor: tileColor,
-		}
+	data, sampledAt, source, err := h.coalescer.do(req.Context(), params.key(target.Name), maxAge, func() TilesData {
+		// The run is shared, so it must not end when the request that started it goes away.
+		return h.sample(context.WithoutCancel(req.Context()), params, nil)
+	})
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+
+		return
 	}
 
-	// Sort by Hostname (descending), then Version (descending)
-	slices.SortFunc(instances, func(a, b InstanceTileData) int {
-		if result := cmp.Compare(b.Info.Hostname, a.Info.Hostname); result != 0 {
-			return result
-		}
+	if data.rateLimited {
+		h.limitExceeded(writer, params, target.Name, limitGlobal, h.globalRetryAfter())
 
-		return cmp.Compare(b.Info.Version, a.Info.Version)
-	})
+		return
+	}
+
+	h.metrics.coalesced.With(string(source)).Inc()
 
-	for i := range instances {
-		instances[i].Index = i + 1
+	if source == sourceFresh {
+		h.cache.store(params.viewKey(target.Name), data, sampledAt)
+	} else {
+		writer.Header().Set("Age", strconv.Itoa(int(time.Since(sampledAt).Seconds())))
 	}
 
-	data := TilesData{
-		Instances: instances,
+	data.colors = params.Colors
+	data.filter = params.Filter
+	data.order = params.Sort
+	data.Refresh = h.autoRefresh(writer, params)
+	data.SampledAt = sampledAt
+	data.Shared = source != sourceFresh
+	data.Freshness = freshness(sampledAt, source)
+
+	h.renderTiles(writer, data)
+}
+
+// freshness describes how old the tiles are and whether they were shared.
+func freshness(sampledAt time.Time, source coalesceSource) string {
+	description := "sampled " + formatRelative(sampledAt, time.Now())
+
+	switch source {
+	case sourceInFlight:
+		return description + ", shared with a concurrent request"
+	case sourceCache:
+		return description + ", from cache"
+	default:
+		return description
 	}
+}
 
-	err := h.templates.ExecuteTemplate(writer, "tiles.gohtml", data)
+// renderTiles colors, filters, orders and renders sampled tiles.
+func (h *FrontendHandler) renderTiles(writer http.ResponseWriter, data TilesData) {
+	err := h.executeTiles(writer, data)
 	if err != nil {
 		http.Error(
 			writer,
@@ -208,20 +358,100 @@ func (h *FrontendHandler) TilesHandler(writer http.ResponseWriter, req *http.Req
 	}
 }
 
+// executeTiles colors, filters, orders and executes the tiles template. Colors are
+// reserved before filtering, so filtered tiles keep their colors.
+func (h *FrontendHandler) executeTiles(writer io.Writer, data TilesData) error {
+	coloring := h.newTileColoring(data.colors)
+	coloring.reserve(data.Instances)
+
+	data.Instances, data.Filter = data.filter.apply(data.Instances)
+
+	now := time.Now()
+
+	for i := range data.Instances {
+		h.decorateTile(&data.Instances[i], coloring, now)
+	}
+
+	data.Legend = coloring.legend()
+
+	slices.SortFunc(data.Instances, data.order.compare)
+
+	for i := range data.Instances {
+		data.Instances[i].Index = i + 1
+	}
+
+	err := h.templates.ExecuteTemplate(writer, "tiles.gohtml", data)
+	if err != nil {
+		return fmt.Errorf("failed to execute tiles template: %w", err)
+	}
+
+	return nil
+}
+
+// decorateTile sets the colors and rendered timestamps of a tile.
+func (h *FrontendHandler) decorateTile(tile *InstanceTileData, coloring *tileColoring, now time.Time) {
+	coloring.decorate(tile)
+	tile.LocalTimestamp = tile.Info.Timestamp.In(h.location).Format(timestampLayout)
+	tile.RelativeTimestamp = formatRelative(tile.Info.Timestamp, now)
+}
+
+// newInstanceClient creates the HTTP client of instance info requests. The default TLS
+// settings are used when tlsConfig is nil.
+func newInstanceClient(tlsConfig *tls.Config) *http.Client {
+	return &http.Client{
+		Timeout: httpClientTimeout,
+		Transport: &http.Transport{
+			MaxIdleConns:        transportMaxIdleConns,
+			IdleConnTimeout:     transportIdleConnTimeout,
+			DisableCompression:  false,
+			DisableKeepAlives:   false,
+			MaxIdleConnsPerHost: transportMaxIdlePerHost,
+			TLSClientConfig:     tlsConfig,
+		},
+	}
+}
+
+// clientFor returns the HTTP client of instance info requests to target.
+func (h *FrontendHandler) clientFor(target Target) *http.Client {
+	if target.client != nil {
+		return target.client
+	}
+
+	return h.instanceClient
+}
+
 func (h *FrontendHandler) fetchInstanceInfo(
 	ctx context.Context,
-) (InstanceInfoResponse, error) {
+	target Target,
+	instanceURL string,
+) (InstanceInfoResponse, http.Header, error) {
 	ctx, cancel := context.WithTimeout(ctx, httpRequestTimeout)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.instanceURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, instanceURL, nil)
 	if err != nil {
-		return InstanceInfoResponse{}, fmt.Errorf("failed to create request: %w", err)
+		return InstanceInfoResponse{}, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := h.instanceClient.Do(req)
+	target.setHeaders(ctx, req)
+
+	jar := cookieJar(ctx)
+	if jar != nil {
+		for _, cookie := range jar.Cookies(req.URL) {
+			req.AddCookie(cookie)
+		}
+	}
+
+	if target.EditRequest != nil {
+		err = target.EditRequest(ctx, req)
+		if err != nil {
+			return InstanceInfoResponse{}, nil, fmt.Errorf("failed to authenticate request: %w", err)
+		}
+	}
+
+	resp, err := h.clientFor(target).Do(req)
 	if err != nil {
-		return InstanceInfoResponse{}, fmt.Errorf(
+		return InstanceInfoResponse{}, nil, fmt.Errorf(
 			"failed to fetch instance info: %w",
 			err,
 		)
@@ -234,20 +464,20 @@ func (h *FrontendHandler) fetchInstanceInfo(
 		}
 	}()
 
+	if jar != nil {
+		jar.SetCookies(req.URL, resp.Cookies())
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return InstanceInfoResponse{}, fmt.Errorf(
-			"%w: %d",
-			ErrUnexpectedStatusCode,
-			resp.StatusCode,
-		)
+		return InstanceInfoResponse{}, nil, &StatusCodeError{StatusCode: resp.StatusCode}
 	}
 
 	var info InstanceInfoResponse
 
 	err = json.NewDecoder(resp.Body).Decode(&info)
 	if err != nil {
-		return InstanceInfoResponse{}, fmt.Errorf("failed to decode response: %w", err)
+		return InstanceInfoResponse{}, nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return info, nil
+	return info, resp.Header, nil
 }
@@ -0,0 +1,56 @@
+package frontend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"phasor-frontend/internal/inventory"
+	"time"
+)
+
+// InstancesData contains data for rendering the instance inventory page.
+type InstancesData struct {
+	Instances []inventory.Entry `json:"instances"`
+}
+
+// InstancesHandler serves the instance inventory as an HTML page.
+func (h *FrontendHandler) InstancesHandler(writer http.ResponseWriter, _ *http.Request) {
+	err := h.templates.ExecuteTemplate(writer, "instances.gohtml", h.instancesData())
+	if err != nil {
+		http.Error(
+			writer,
+			fmt.Sprintf("failed to render instances: %v", err),
+			http.StatusInternalServerError,
+		)
+
+		return
+	}
+}
+
+// InstancesJSONHandler serves the instance inventory as JSON.
+func (h *FrontendHandler) InstancesJSONHandler(writer http.ResponseWriter, _ *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+
+	err := json.NewEncoder(writer).Encode(h.instancesData())
+	if err != nil {
+		http.Error(
+			writer,
+			fmt.Sprintf("failed to encode instances: %v", err),
+			http.StatusInternalServerError,
+		)
+
+		return
+	}
+}
+
+func (h *FrontendHandler) instancesData() InstancesData {
+	data := InstancesData{
+		Instances: []inventory.Entry{},
+	}
+
+	if h.inventory != nil {
+		data.Instances = h.inventory.Entries(time.Now())
+	}
+
+	return data
+}
@@ -0,0 +1,233 @@
+package frontend
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"phasor-frontend/internal/ratelimit"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	limitGlobal = "global"
+	limitClient = "client"
+
+	// maxCachedViews caps the number of views with cached tiles; the oldest is dropped first.
+	maxCachedViews = 256
+	// cachedTilesMaxAge is how long cached tiles can stand in for fresh samples.
+	cachedTilesMaxAge = 10 * time.Minute
+)
+
+// Limits protects the backend from the dashboard. Every limit is disabled when nil or zero.
+type Limits struct {
+	// Samples limits the rate of outgoing samples across all clients.
+	Samples *ratelimit.Bucket
+	// MaxConcurrentSamples caps the number of outgoing samples in flight. Retries and
+	// hedged requests of a sample run within the slot of the sample.
+	MaxConcurrentSamples int
+	// Clients limits the rate of tile requests per client.
+	Clients *ratelimit.Clients
+	// TrustForwardedFor identifies clients by the first X-Forwarded-For address.
+	TrustForwardedFor bool
+	// Reject answers 429 when a limit is hit, instead of rendering cached samples.
+	Reject bool
+}
+
+// WithLimits applies rate and concurrency limits to tile requests and outgoing samples.
+func WithLimits(limits Limits) HandlerOption {
+	return func(h *FrontendHandler) {
+		h.limits = limits
+
+		if limits.MaxConcurrentSamples > 0 {
+			h.sampleSlots = make(chan struct{}, limits.MaxConcurrentSamples)
+		}
+	}
+}
+
+// cachedTiles are the last tiles rendered for a request.
+type cachedTiles struct {
+	data TilesData
+	at   time.Time
+}
+
+// tilesCache keeps the last rendered tiles of up to maxCachedViews views, safe for
+// concurrent use. Views include the forwarded headers, so the tiles of one user are never
+// shown to another.
+type tilesCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedTiles
+}
+
+// store caches the tiles of a view, dropping tiles older than cachedTilesMaxAge and, when
+// the cache is full, the oldest tiles.
+func (c *tilesCache) store(view string, data TilesData, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]cachedTiles)
+	}
+
+	c.pruneLocked(at)
+
+	if _, found := c.entries[view]; !found && len(c.entries) >= maxCachedViews {
+		oldest := ""
+		for key, cached := range c.entries {
+			if oldest == "" || cached.at.Before(c.entries[oldest].at) {
+				oldest = key
+			}
+		}
+
+		delete(c.entries, oldest)
+	}
+
+	data.Instances = slices.Clone(data.Instances)
+	c.entries[view] = cachedTiles{data: data, at: at}
+}
+
+// load returns a copy of the cached tiles of a view, safe to modify.
+func (c *tilesCache) load(view string, now time.Time) (cachedTiles, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, found := c.entries[view]
+	if !found || now.Sub(cached.at) > cachedTilesMaxAge {
+		return cachedTiles{}, false
+	}
+
+	cached.data.Instances = slices.Clone(cached.data.Instances)
+
+	return cached, true
+}
+
+// pruneLocked removes tiles older than cachedTilesMaxAge.
+func (c *tilesCache) pruneLocked(now time.Time) {
+	for key, cached := range c.entries {
+		if now.Sub(cached.at) > cachedTilesMaxAge {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// acquireSample takes a concurrency slot and a token for an outgoing sample. It returns
+// false when the global rate limit is exhausted; the slot must be released otherwise.
+// The token is taken once the slot is acquired, so requests canceled while waiting for
+// a slot do not spend tokens.
+func (h *FrontendHandler) acquireSample(ctx context.Context) (func(), bool, error) {
+	if h.sampleSlots != nil {
+		select {
+		case h.sampleSlots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, true, fmt.Errorf("failed to acquire sample slot: %w", ctx.Err())
+		}
+	}
+
+	releaseSlot := func() {
+		if h.sampleSlots != nil {
+			<-h.sampleSlots
+		}
+	}
+
+	if h.limits.Samples != nil {
+		allowed, _ := h.limits.Samples.Take(time.Now())
+		if !allowed {
+			releaseSlot()
+
+			return nil, false, nil
+		}
+	}
+
+	h.metrics.inFlight.Inc()
+
+	return func() {
+		h.metrics.inFlight.Dec()
+		releaseSlot()
+	}, true, nil
+}
+
+// clientLimited reports whether the client of the request exceeded its rate limit, and
+// how long it has to wait for the next request.
+func (h *FrontendHandler) clientLimited(req *http.Request) (bool, time.Duration) {
+	if h.limits.Clients == nil {
+		return false, 0
+	}
+
+	allowed, wait := h.limits.Clients.Take(h.clientKey(req), time.Now())
+
+	return !allowed, wait
+}
+
+// clientKey identifies the client of a request by its address.
+func (h *FrontendHandler) clientKey(req *http.Request) string {
+	if h.limits.TrustForwardedFor {
+		if forwarded := req.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+
+			return strings.TrimSpace(first)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
+
+// globalRetryAfter returns how long it takes until the global rate limit admits a sample.
+func (h *FrontendHandler) globalRetryAfter() time.Duration {
+	if h.limits.Samples == nil {
+		return 0
+	}
+
+	return h.limits.Samples.Wait(time.Now())
+}
+
+// limitExceeded answers a tile request that hit a limit, with cached tiles of an identical
+// request when available and allowed, and with 429 Too Many Requests otherwise.
+func (h *FrontendHandler) limitExceeded(
+	writer http.ResponseWriter,
+	params tilesParams,
+	target string,
+	limit string,
+	retryAfter time.Duration,
+) {
+	h.metrics.rateLimited.With(limit).Inc()
+
+	if !h.limits.Reject {
+		cached, found := h.cache.load(params.viewKey(target), time.Now())
+		if found {
+			h.metrics.degradedRenders.Inc()
+
+			cached.data.colors = params.Colors
+			cached.data.filter = params.Filter
+			cached.data.order = params.Sort
+			cached.data.Refresh = h.autoRefresh(writer, params)
+			cached.data.SampledAt = cached.at
+			cached.data.Freshness = freshness(cached.at, sourceCache)
+			cached.data.Degraded = fmt.Sprintf(
+				"%s rate limit reached, showing samples from %s ago",
+				limit,
+				time.Since(cached.at).Round(time.Second),
+			)
+			h.renderTiles(writer, cached.data)
+
+			return
+		}
+	}
+
+	rejectLimited(writer, limit, retryAfter)
+}
+
+// rejectLimited answers a request that hit a limit with 429 Too Many Requests.
+func rejectLimited(writer http.ResponseWriter, limit string, retryAfter time.Duration) {
+	writer.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(retryAfter.Seconds())))))
+	http.Error(writer, fmt.Sprintf("%s rate limit exceeded", limit), http.StatusTooManyRequests)
+}
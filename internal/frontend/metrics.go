@@ -0,0 +1,58 @@
+package frontend
+
+import (
+	"phasor-frontend/internal/metrics"
+	"time"
+)
+
+// WithMetrics registers the metrics of the handler in registry.
+func WithMetrics(registry *metrics.Registry) HandlerOption {
+	return func(h *FrontendHandler) { h.registry = registry }
+}
+
+// handlerMetrics are the metrics of the frontend handler.
+type handlerMetrics struct {
+	samples         *metrics.CounterVec
+	rateLimited     *metrics.CounterVec
+	degradedRenders *metrics.Counter
+	inFlight        *metrics.Gauge
+	coalesced       *metrics.CounterVec
+}
+
+func newHandlerMetrics(registry *metrics.Registry, limits Limits) *handlerMetrics {
+	handlerMetrics := &handlerMetrics{
+		samples: registry.NewCounterVec(
+			"phasor_frontend_samples_total", "Samples of backend instances by result.", "result",
+		),
+		rateLimited: registry.NewCounterVec(
+			"phasor_frontend_rate_limited_total", "Tile requests that hit a rate limit, by limit.", "limit",
+		),
+		degradedRenders: registry.NewCounter(
+			"phasor_frontend_degraded_renders_total", "Tile requests answered from cached samples.",
+		),
+		inFlight: registry.NewGauge(
+			"phasor_frontend_samples_in_flight", "Outgoing samples currently in flight.",
+		),
+		coalesced: registry.NewCounterVec(
+			"phasor_frontend_tile_requests_total",
+			"Tile requests by source: fresh sampling run, shared in-flight run or cache.",
+			"source",
+		),
+	}
+
+	if limits.Samples != nil {
+		registry.NewGaugeFunc(
+			"phasor_frontend_sample_tokens", "Tokens available in the global sample rate limit.",
+			func() float64 { return limits.Samples.Available(time.Now()) },
+		)
+	}
+
+	if limits.MaxConcurrentSamples > 0 {
+		registry.NewGaugeFunc(
+			"phasor_frontend_max_concurrent_samples", "Maximum number of outgoing samples in flight.",
+			func() float64 { return float64(limits.MaxConcurrentSamples) },
+		)
+	}
+
+	return handlerMetrics
+}
@@ -0,0 +1,41 @@
+package frontend
+
+import "cmp"
+
+// tileOrder orders the rendered tiles.
+type tileOrder string
+
+const (
+	// orderHostname orders tiles by hostname, then version, both descending.
+	orderHostname tileOrder = "hostname"
+	// orderVersion puts the newest version first, then orders by hostname descending.
+	orderVersion tileOrder = "version"
+	// orderLatency puts the slowest samples first, then orders by hostname descending.
+	orderLatency tileOrder = "latency"
+)
+
+// parseTileOrder reads the order of the tiles, falling back to hostname for unknown values.
+func parseTileOrder(value string) tileOrder {
+	switch order := tileOrder(value); order {
+	case orderVersion, orderLatency:
+		return order
+	default:
+		return orderHostname
+	}
+}
+
+// compare orders two tiles for rendering.
+func (o tileOrder) compare(a, b InstanceTileData) int {
+	byHostname := func() int {
+		return cmp.Or(cmp.Compare(b.Info.Hostname, a.Info.Hostname), cmp.Compare(b.Info.Version, a.Info.Version))
+	}
+
+	switch o {
+	case orderVersion:
+		return cmp.Or(compareVersions(b.Info.Version, a.Info.Version), byHostname())
+	case orderLatency:
+		return cmp.Or(cmp.Compare(b.Latency, a.Latency), byHostname())
+	default:
+		return byHostname()
+	}
+}
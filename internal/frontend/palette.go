@@ -0,0 +1,253 @@
+package frontend
+
+import (
+	"cmp"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"slices"
+	"strings"
+)
+
+// ColorAssignment decides how tile colors are assigned to instances.
+type ColorAssignment string
+
+const (
+	// ColorAssignmentDistinct gives distinct keys of one view distinct colors. Keys start at
+	// their hashed color and move on to the next free one when it is taken, so colors stay
+	// stable across requests unless keys collide.
+	ColorAssignmentDistinct ColorAssignment = "distinct"
+	// ColorAssignmentHash gives every key its hashed color, which may be shared by other keys.
+	ColorAssignmentHash ColorAssignment = "hash"
+)
+
+const (
+	// generatedLightness and generatedChroma place generated colors in OKLCH where they stay
+	// within sRGB for most hues and read well on light and dark backgrounds.
+	generatedLightness = 0.72
+	generatedChroma    = 0.14
+	// goldenAngle spaces the hues of generated colors as far apart as possible.
+	goldenAngle        = 137.50776
+	generatedHueOffset = 25.0
+	fullCircle         = 360.0
+)
+
+// PaletteTileColors names the configured tile colors among the palettes.
+const PaletteTileColors = "tile_colors"
+
+// builtinPalettes are color-blind-safe palettes: Okabe-Ito with grey in place of black,
+// the bright and muted schemes by Paul Tol, and the IBM Design palette.
+var builtinPalettes = map[string][]string{
+	"okabe_ito":  {"#e69f00", "#56b4e9", "#009e73", "#f0e442", "#0072b2", "#d55e00", "#cc79a7", "#999999"},
+	"tol_bright": {"#4477aa", "#ee6677", "#228833", "#ccbb44", "#66ccee", "#aa3377", "#bbbbbb"},
+	"tol_muted": {
+		"#332288", "#88ccee", "#44aa99", "#117733", "#999933", "#ddcc77", "#cc6677", "#882255", "#aa4499",
+	},
+	"ibm": {"#648fff", "#785ef0", "#dc267f", "#fe6100", "#ffb000"},
+}
+
+// IsPalette reports whether name is a built-in palette or the configured tile colors.
+func IsPalette(name string) bool {
+	_, builtin := builtinPalettes[name]
+
+	return builtin || name == PaletteTileColors
+}
+
+// WithPalette colors tiles with a built-in palette instead of the configured tile colors.
+func WithPalette(name string) HandlerOption {
+	return func(h *FrontendHandler) {
+		if IsPalette(name) {
+			h.palette = name
+		}
+	}
+}
+
+// paletteColors returns the colors of the named palette, or the configured tile colors.
+func (h *FrontendHandler) paletteColors(name string) []string {
+	if colors, found := builtinPalettes[name]; found {
+		return colors
+	}
+
+	return h.tileColors
+}
+
+// WithColorAssignment sets how tile colors are assigned. Colors are distinct within a view by default.
+func WithColorAssignment(assignment ColorAssignment) HandlerOption {
+	return func(h *FrontendHandler) {
+		if assignment != "" {
+			h.colorAssignment = assignment
+		}
+	}
+}
+
+// colorPalette assigns colors to keys for a single view.
+type colorPalette struct {
+	colors     []string
+	assignment ColorAssignment
+	// pinned maps keys to fixed colors, which are not handed out to other keys.
+	pinned    map[string]string
+	assigned  map[string]string
+	taken     []bool
+	generated int
+}
+
+// newColorPalette creates a new color palette with the given colors and pinned keys.
+func newColorPalette(colors []string, assignment ColorAssignment, pinned map[string]string) *colorPalette {
+	palette := &colorPalette{
+		colors:     colors,
+		assignment: assignment,
+		pinned:     pinned,
+		assigned:   make(map[string]string),
+		taken:      make([]bool, len(colors)),
+	}
+
+	for _, color := range pinned {
+		if slot := slices.Index(colors, color); slot >= 0 {
+			palette.taken[slot] = true
+		}
+	}
+
+	return palette
+}
+
+// getColor returns the color of key. Pinned keys always get their pinned color. With hash
+// assignment the same key always returns the same color. With distinct assignment a key
+// keeps its hashed color unless another key of the view took it first; generated colors
+// are used once the palette is exhausted.
+func (cp *colorPalette) getColor(key string) string {
+	if color, found := cp.assigned[key]; found {
+		return color
+	}
+
+	if color, found := cp.pinned[key]; found {
+		cp.assigned[key] = color
+
+		return color
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	idx := int(h.Sum32()) % len(cp.colors)
+
+	color := ""
+
+	if cp.assignment == ColorAssignmentHash {
+		color = cp.colors[idx]
+	}
+
+	for i := 0; color == "" && i < len(cp.colors); i++ {
+		slot := (idx + i) % len(cp.colors)
+		if !cp.taken[slot] {
+			cp.taken[slot] = true
+			color = cp.colors[slot]
+		}
+	}
+
+	if color == "" {
+		color = generatedColor(cp.generated)
+		cp.generated++
+	}
+
+	cp.assigned[key] = color
+
+	return color
+}
+
+// reserve assigns colors to keys in sorted order, so collisions are resolved the same way
+// whatever order the tiles of a view are colored in.
+func (cp *colorPalette) reserve(keys []string) {
+	for _, key := range slices.Compact(slices.Sorted(slices.Values(keys))) {
+		cp.getColor(key)
+	}
+}
+
+// entries lists the colors the palette handed out, ordered by value.
+func (cp *colorPalette) entries() []LegendEntry {
+	entries := make([]LegendEntry, 0, len(cp.assigned))
+
+	for key, color := range cp.assigned {
+		_, pinned := cp.pinned[key]
+		entries = append(entries, LegendEntry{Value: colorValue(key), Color: color, Pinned: pinned, key: key})
+	}
+
+	slices.SortFunc(entries, func(a, b LegendEntry) int { return cmp.Compare(a.Value, b.Value) })
+
+	return entries
+}
+
+// explains reports whether every color handed out stands for a value of the given attribute
+// and none of them is pinned, so the colored text explains the color by itself.
+func (cp *colorPalette) explains(attribute ColorAttribute) bool {
+	prefix := colorKey(attribute, "")
+
+	for key := range cp.assigned {
+		if _, pinned := cp.pinned[key]; pinned || !strings.HasPrefix(key, prefix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// generatedColor returns the n-th extra color. Generated colors share lightness and chroma
+// in OKLCH, so they are perceptually equally prominent, and differ by hue.
+func generatedColor(n int) string {
+	hue := math.Mod(generatedHueOffset+float64(n)*goldenAngle, fullCircle) * math.Pi / (fullCircle / 2)
+
+	return oklchToHex(generatedLightness, generatedChroma, hue)
+}
+
+// oklabToLMS and lmsToLinearSRGB are the OKLab conversion matrices by Bjorn Ottosson.
+var (
+	oklabToLMS = [3][2]float64{
+		{+0.3963377774, +0.2158037573},
+		{-0.1055613458, -0.0638541728},
+		{-0.0894841775, -1.2914855480},
+	}
+	lmsToLinearSRGB = [3][3]float64{
+		{+4.0767416621, -3.3077115913, +0.2309699292},
+		{-1.2684380046, +2.6097574011, -0.3413193965},
+		{-0.0041960863, -0.7034186147, +1.7076147010},
+	}
+)
+
+// sRGB transfer function constants.
+const (
+	srgbLinearLimit = 0.0031308
+	srgbLinearSlope = 12.92
+	srgbGamma       = 2.4
+	srgbOffset      = 0.055
+	maxChannel      = 255
+)
+
+// oklchToHex converts an OKLCH color with the hue in radians to an sRGB hex color.
+// Channels outside of sRGB are clipped.
+func oklchToHex(lightness, chroma, hue float64) string {
+	a := chroma * math.Cos(hue)
+	b := chroma * math.Sin(hue)
+
+	var lms [3]float64
+	for i, row := range oklabToLMS {
+		cone := lightness + row[0]*a + row[1]*b
+		lms[i] = cone * cone * cone
+	}
+
+	var channels [3]int
+	for i, row := range lmsToLinearSRGB {
+		channels[i] = srgbChannel(row[0]*lms[0] + row[1]*lms[1] + row[2]*lms[2])
+	}
+
+	return fmt.Sprintf("#%02x%02x%02x", channels[0], channels[1], channels[2])
+}
+
+// srgbChannel gamma-encodes a linear channel and scales it to a byte.
+func srgbChannel(linear float64) int {
+	linear = min(max(linear, 0), 1)
+
+	encoded := srgbLinearSlope * linear
+	if linear > srgbLinearLimit {
+		encoded = (1+srgbOffset)*math.Pow(linear, 1/srgbGamma) - srgbOffset
+	}
+
+	return int(math.Round(encoded * maxChannel))
+}
@@ -0,0 +1,151 @@
+package frontend
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// samplingModeFixed takes exactly count samples.
+	samplingModeFixed = "fixed"
+	// samplingModeAdaptive samples until no new instances show up for a while.
+	samplingModeAdaptive = "adaptive"
+	// samplingModeSticky takes count samples per simulated client, each within one session.
+	samplingModeSticky = "sticky"
+
+	defaultPatience = 10
+	maxPatience     = 50
+	defaultBudget   = 50
+	maxBudget       = 200
+)
+
+// tilesParams holds the query parameters accepted by the tiles endpoint.
+type tilesParams struct {
+	Count    int
+	Target   string
+	Mode     string
+	Patience int // Samples without a new instance after which adaptive sampling stops
+	Budget   int // Maximum number of samples in adaptive mode
+	Clients  int // Simulated clients in sticky mode
+	// ExpectedSplit overrides the expected split of the target, e.g. expect=1.2.0:80,1.3.0:20
+	// or expect=1.2.*:80,1.3.*:20.
+	ExpectedSplit map[string]float64
+	// Stream renders tiles progressively as their samples complete.
+	Stream bool
+	// Timeout lowers the time budget of the request, e.g. timeout=2s.
+	Timeout time.Duration
+	// Refresh is the auto-refresh interval of the view, e.g. refresh=30s. Zero turns it off.
+	Refresh time.Duration
+	// Group names a captured response header the summary groups samples by, e.g. group=x-served-by.
+	Group string
+	// Colors holds the color choices: hostname_color and version_color name the attributes
+	// behind the tile colors, e.g. version_color=header:x-zone, palette a palette and markers
+	// turns shape markers on or off.
+	Colors colorChoice
+	// Filter narrows the rendered tiles, e.g. version=1.2.0, exclude_version=1.1.0,
+	// hostname=web-* and only=errors or only=outdated.
+	Filter tileFilter
+	// Sort orders the rendered tiles by hostname (default), version or latency, e.g. sort=version.
+	Sort tileOrder
+	// Canary asks for samples to be routed to the canary of the target.
+	Canary bool
+	// Forwarded holds the browser headers and cookies forwarded to the target.
+	Forwarded http.Header
+}
+
+// parseTilesParams reads tile parameters from the query, falling back to defaults
+// for missing or invalid values.
+func parseTilesParams(query url.Values) tilesParams {
+	params := tilesParams{
+		Count:         parseBoundedInt(query.Get("count"), defaultTileCount, maxTileCount),
+		Target:        query.Get("target"),
+		Mode:          samplingModeFixed,
+		Patience:      parseBoundedInt(query.Get("patience"), defaultPatience, maxPatience),
+		Budget:        parseBoundedInt(query.Get("budget"), defaultBudget, maxBudget),
+		Clients:       parseBoundedInt(query.Get("clients"), defaultClients, maxClients),
+		ExpectedSplit: parseSplit(query.Get("expect")),
+		Stream:        query.Get("stream") == "true",
+		Timeout:       parseDuration(query.Get("timeout")),
+		Refresh:       parseDuration(query.Get("refresh")),
+		Canary:        query.Get("canary") == "true",
+		Group:         strings.ToLower(strings.TrimSpace(query.Get("group"))),
+		Filter:        parseTileFilter(query),
+		Sort:          parseTileOrder(query.Get("sort")),
+	}
+
+	params.Colors.ColorBy.Hostname, _ = ParseColorAttribute(query.Get("hostname_color"))
+	params.Colors.ColorBy.Version, _ = ParseColorAttribute(query.Get("version_color"))
+
+	if palette := query.Get("palette"); IsPalette(palette) {
+		params.Colors.Palette = palette
+	}
+
+	markers, err := strconv.ParseBool(query.Get("markers"))
+	if err == nil {
+		params.Colors.Markers = &markers
+	}
+
+	switch query.Get("mode") {
+	case samplingModeAdaptive, samplingModeSticky:
+		params.Mode = query.Get("mode")
+	}
+
+	return params
+}
+
+// parseBoundedInt parses a positive integer up to maxValue, returning defaultValue otherwise.
+func parseBoundedInt(value string, defaultValue, maxValue int) int {
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 || parsed > maxValue {
+		return defaultValue
+	}
+
+	return parsed
+}
+
+// parseDuration parses a positive duration, returning zero for missing or invalid values.
+func parseDuration(value string) time.Duration {
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0
+	}
+
+	return timeout
+}
+
+// parseSplit parses a comma-separated list of version:share pairs. Malformed or
+// negative entries are skipped.
+func parseSplit(value string) map[string]float64 {
+	if value == "" {
+		return nil
+	}
+
+	split := make(map[string]float64)
+
+	for entry := range strings.SplitSeq(value, ",") {
+		sep := strings.LastIndex(entry, ":")
+		if sep <= 0 {
+			continue
+		}
+
+		share, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(entry[sep+1:]), "%"), 64)
+		if err != nil || share < 0 {
+			continue
+		}
+
+		split[strings.TrimSpace(entry[:sep])] = share
+	}
+
+	if len(split) == 0 {
+		return nil
+	}
+
+	return split
+}
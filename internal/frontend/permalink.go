@@ -0,0 +1,161 @@
+package frontend
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// pushURLHeader tells htmx which URL to push to the browser history.
+	pushURLHeader = "HX-Push-Url"
+	// htmxRequestHeader and htmxTriggerHeader are sent by htmx with the id of the element
+	// that triggered the request.
+	htmxRequestHeader = "HX-Request"
+	htmxTriggerHeader = "HX-Trigger"
+)
+
+// refreshChoices are the auto-refresh intervals offered by the control of index.gohtml.
+var refreshChoices = []time.Duration{10 * time.Second, 30 * time.Second, time.Minute, 5 * time.Minute}
+
+// IndexData contains data for rendering the index page. The view fields render the
+// parameters of a permalink into the controls.
+type IndexData struct {
+	Count   int
+	Targets []string
+	Target  string
+	Mode    string
+	Clients int
+	// Expect is the expected split, e.g. 1.2.0:80,1.3.0:20.
+	Expect        string
+	Group         string
+	HostnameColor string
+	VersionColor  string
+	Palette       string
+	// Markers is true or false when the view turns markers on or off, and empty otherwise.
+	Markers string
+	Stream  bool
+	Canary  bool
+	// Refresh is the auto-refresh interval, e.g. 30s, and empty when auto-refresh is off.
+	Refresh string
+	// CustomRefresh is set when Refresh is not one of the offered intervals, so the control
+	// renders it as an extra option.
+	CustomRefresh bool
+	// Version, ExcludeVersion, Hostname and Only render the tile filter.
+	Version        string
+	ExcludeVersion string
+	Hostname       string
+	Only           string
+	// Sort is the order of the tiles: hostname, version or latency.
+	Sort string
+	// TilesQuery is the query of the tiles request of the view.
+	TilesQuery string
+}
+
+// indexData returns the index page of the view described by params.
+func (h *FrontendHandler) indexData(params tilesParams) IndexData {
+	targetNames := make([]string, len(h.targets))
+	for i, target := range h.targets {
+		targetNames[i] = target.Name
+	}
+
+	data := IndexData{
+		Count:          params.Count,
+		Targets:        targetNames,
+		Target:         params.Target,
+		Mode:           params.Mode,
+		Clients:        params.Clients,
+		Expect:         formatSplit(params.ExpectedSplit),
+		Group:          params.Group,
+		HostnameColor:  string(params.Colors.ColorBy.Hostname),
+		VersionColor:   string(params.Colors.ColorBy.Version),
+		Palette:        params.Colors.Palette,
+		Stream:         params.Stream,
+		Canary:         params.Canary,
+		Version:        strings.Join(params.Filter.Versions, ","),
+		ExcludeVersion: strings.Join(params.Filter.ExcludeVersions, ","),
+		Hostname:       params.Filter.Hostname,
+		Only:           params.Filter.Only,
+		Sort:           string(params.Sort),
+		TilesQuery:     params.values().Encode(),
+	}
+
+	if params.Refresh > 0 {
+		data.Refresh = params.Refresh.String()
+		data.CustomRefresh = !slices.Contains(refreshChoices, params.Refresh)
+	}
+
+	if params.Colors.Markers != nil {
+		data.Markers = strconv.FormatBool(*params.Colors.Markers)
+	}
+
+	return data
+}
+
+// values encodes the parameters that differ from their defaults, so the view can be
+// restored from a permalink.
+func (p tilesParams) values() url.Values {
+	values := url.Values{}
+	values.Set("count", strconv.Itoa(p.Count))
+
+	setIf := func(name, value string, set bool) {
+		if set {
+			values.Set(name, value)
+		}
+	}
+
+	setIf("target", p.Target, p.Target != "")
+	setIf("mode", p.Mode, p.Mode != samplingModeFixed)
+	setIf("patience", strconv.Itoa(p.Patience), p.Mode == samplingModeAdaptive && p.Patience != defaultPatience)
+	setIf("budget", strconv.Itoa(p.Budget), p.Mode == samplingModeAdaptive && p.Budget != defaultBudget)
+	setIf("clients", strconv.Itoa(p.Clients), p.Mode == samplingModeSticky && p.Clients != defaultClients)
+	setIf("expect", formatSplit(p.ExpectedSplit), p.ExpectedSplit != nil)
+	setIf("timeout", p.Timeout.String(), p.Timeout > 0)
+	setIf("refresh", p.Refresh.String(), p.Refresh > 0)
+	setIf("group", p.Group, p.Group != "")
+	setIf("sort", string(p.Sort), p.Sort != orderHostname)
+	setIf("hostname_color", string(p.Colors.ColorBy.Hostname), p.Colors.ColorBy.Hostname != "")
+	setIf("version_color", string(p.Colors.ColorBy.Version), p.Colors.ColorBy.Version != "")
+	setIf("palette", p.Colors.Palette, p.Colors.Palette != "")
+	setIf("stream", "true", p.Stream)
+	setIf("canary", "true", p.Canary)
+
+	if p.Colors.Markers != nil {
+		values.Set("markers", strconv.FormatBool(*p.Colors.Markers))
+	}
+
+	p.Filter.setValues(values)
+
+	return values
+}
+
+// pushPermalink makes htmx push the index page of the view to the browser history when
+// the user changed the view, so the address bar always holds a link to it. Loading the
+// tiles of the index page itself and refreshing them push nothing.
+func pushPermalink(writer http.ResponseWriter, req *http.Request, params tilesParams) {
+	if req.Header.Get(htmxRequestHeader) != "true" {
+		return
+	}
+
+	switch req.Header.Get(htmxTriggerHeader) {
+	case tilesContainerID, refreshID:
+		return
+	}
+
+	writer.Header().Set(pushURLHeader, "/?"+params.values().Encode())
+}
+
+// formatSplit renders an expected split as parsed by parseSplit, ordered by version.
+func formatSplit(split map[string]float64) string {
+	entries := make([]string, 0, len(split))
+	for _, version := range slices.Sorted(maps.Keys(split)) {
+		entries = append(entries, fmt.Sprintf("%s:%g", version, split[version]))
+	}
+
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,127 @@
+package frontend
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+)
+
+// kubernetesPodName matches pods of Deployments and Argo Rollouts, e.g. phasor-backend-7d9f8c6b5-x2kqz.
+// The revision is the pod-template-hash of the ReplicaSet; hash and suffix use the
+// alphabet Kubernetes generates names from.
+var kubernetesPodName = regexp.MustCompile(`^(?P<workload>[a-z0-9]([-a-z0-9]*[a-z0-9])?)` +
+	`-(?P<revision>[bcdfghjklmnpqrstvwxz2456789]{6,10})-(?P<pod>[bcdfghjklmnpqrstvwxz2456789]{5})$`)
+
+// PodName is a hostname split into the workload, the revision of the workload and the
+// suffix of the pod. It is zero when no hostname pattern matches.
+type PodName struct {
+	Workload string
+	Revision string
+	Suffix   string
+}
+
+// WithHostnamePatterns sets the patterns that split hostnames into pod names, tried in
+// order. Patterns name their parts with the groups workload, revision and pod. Kubernetes
+// pod naming is used when no patterns are given.
+func WithHostnamePatterns(patterns []*regexp.Regexp) HandlerOption {
+	return func(h *FrontendHandler) {
+		if len(patterns) > 0 {
+			h.hostnamePatterns = patterns
+		}
+	}
+}
+
+// parsePodName splits a hostname with the first matching pattern.
+func parsePodName(patterns []*regexp.Regexp, hostname string) PodName {
+	for _, pattern := range patterns {
+		match := pattern.FindStringSubmatch(hostname)
+		if match == nil {
+			continue
+		}
+
+		var name PodName
+
+		for i, group := range pattern.SubexpNames() {
+			switch group {
+			case "workload":
+				name.Workload = match[i]
+			case "revision":
+				name.Revision = match[i]
+			case "pod":
+				name.Suffix = match[i]
+			}
+		}
+
+		return name
+	}
+
+	return PodName{}
+}
+
+// RevisionKey identifies the revision across workloads, or is empty when the revision is unknown.
+func (n PodName) RevisionKey() string {
+	if n.Revision == "" {
+		return ""
+	}
+
+	if n.Workload == "" {
+		return n.Revision
+	}
+
+	return n.Workload + "-" + n.Revision
+}
+
+// RevisionShare is the number of samples answered by pods of a single revision.
+type RevisionShare struct {
+	Workload string
+	Revision string
+	Count    int
+	// Versions lists the versions reported by pods of the revision.
+	Versions []string
+	// Pods counts the distinct pods of the revision that answered.
+	Pods int
+}
+
+// revisionShares groups the answered samples by the revision of their pods, largest first.
+func revisionShares(instances []InstanceTileData) []RevisionShare {
+	shares := make(map[string]*RevisionShare)
+	pods := make(map[string]map[string]bool)
+
+	for _, instance := range instances {
+		key := instance.Pod.RevisionKey()
+		if !instance.Reachable || key == "" {
+			continue
+		}
+
+		share, found := shares[key]
+		if !found {
+			share = &RevisionShare{Workload: instance.Pod.Workload, Revision: instance.Pod.Revision}
+			shares[key] = share
+			pods[key] = make(map[string]bool)
+		}
+
+		share.Count++
+		pods[key][instance.Info.Hostname] = true
+
+		if !slices.Contains(share.Versions, instance.Info.Version) {
+			share.Versions = append(share.Versions, instance.Info.Version)
+		}
+	}
+
+	result := make([]RevisionShare, 0, len(shares))
+	for key, share := range shares {
+		share.Pods = len(pods[key])
+		slices.Sort(share.Versions)
+		result = append(result, *share)
+	}
+
+	slices.SortFunc(result, func(a, b RevisionShare) int {
+		return cmp.Or(
+			cmp.Compare(b.Count, a.Count),
+			cmp.Compare(a.Workload, b.Workload),
+			cmp.Compare(a.Revision, b.Revision),
+		)
+	})
+
+	return result
+}
@@ -0,0 +1,80 @@
+package frontend
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultMinRefresh is the shortest auto-refresh interval unless configured otherwise.
+	defaultMinRefresh = 5 * time.Second
+	// slowDownFactor stretches the refresh interval while the backend is protected by its limits.
+	slowDownFactor = 2
+	// busySlotsPercent is the share of sample slots in use above which the server is under load.
+	busySlotsPercent = 80
+	// refreshHeader tells clients the refresh interval in seconds the server asks them to use.
+	refreshHeader = "X-Refresh-Interval"
+	// refreshID is the id of the element that polls the tiles, rendered with the tiles.
+	refreshID = "tiles-refresh"
+)
+
+// AutoRefresh describes how the tiles poll for fresh samples.
+type AutoRefresh struct {
+	// Interval is the requested interval, raised to the minimum and stretched under load.
+	Interval time.Duration
+	// Raised is set when the requested interval was below the minimum of the server.
+	Raised bool
+	// SlowedDown is set when the server stretched the interval because it is under load.
+	SlowedDown bool
+	// Query is the query of the tiles request that refreshes the view.
+	Query string
+}
+
+// Seconds returns the interval in whole seconds, as used by htmx polling.
+func (r *AutoRefresh) Seconds() int {
+	return int(r.Interval.Round(time.Second).Seconds())
+}
+
+// WithMinRefreshInterval sets the shortest auto-refresh interval clients may use.
+func WithMinRefreshInterval(interval time.Duration) HandlerOption {
+	return func(h *FrontendHandler) {
+		if interval > 0 {
+			h.minRefresh = interval
+		}
+	}
+}
+
+// autoRefresh returns the auto-refresh of the view, or nil when it is off. The interval is
+// raised to the minimum and doubled while the server is under load, and sent to the client
+// in the X-Refresh-Interval header.
+func (h *FrontendHandler) autoRefresh(writer http.ResponseWriter, params tilesParams) *AutoRefresh {
+	if params.Refresh <= 0 {
+		return nil
+	}
+
+	refresh := &AutoRefresh{Interval: params.Refresh, Query: params.values().Encode()}
+
+	if refresh.Interval < h.minRefresh {
+		refresh.Interval = h.minRefresh
+		refresh.Raised = true
+	}
+
+	if h.underLoad() {
+		refresh.Interval *= slowDownFactor
+		refresh.SlowedDown = true
+	}
+
+	writer.Header().Set(refreshHeader, strconv.Itoa(refresh.Seconds()))
+
+	return refresh
+}
+
+// underLoad reports whether the global sample rate limit is exhausted or most sample slots are busy.
+func (h *FrontendHandler) underLoad() bool {
+	if h.limits.Samples != nil && h.limits.Samples.Available(time.Now()) < 1 {
+		return true
+	}
+
+	return h.sampleSlots != nil && len(h.sampleSlots)*percent >= cap(h.sampleSlots)*busySlotsPercent
+}
@@ -0,0 +1,44 @@
+package frontend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// StatusCodeError is returned when the instance API answers with a non-200 status code.
+// It matches ErrUnexpectedStatusCode with errors.Is.
+type StatusCodeError struct {
+	StatusCode int
+}
+
+func (e *StatusCodeError) Error() string {
+	return fmt.Sprintf("%v: %d", ErrUnexpectedStatusCode, e.StatusCode)
+}
+
+// Is reports whether target is ErrUnexpectedStatusCode.
+func (e *StatusCodeError) Is(target error) bool {
+	return target == ErrUnexpectedStatusCode
+}
+
+// IsRetryable reports whether a failed instance info request is worth retrying. Transport
+// errors and overload or gateway statuses are transient; other statuses and malformed
+// responses will not change on retry.
+func IsRetryable(err error) bool {
+	var statusErr *StatusCodeError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		default:
+			return false
+		}
+	}
+
+	var syntaxErr *json.SyntaxError
+
+	var typeErr *json.UnmarshalTypeError
+
+	return !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr)
+}
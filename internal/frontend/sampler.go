@@ -0,0 +1,332 @@
+package frontend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"phasor-frontend/internal/inventory"
+	"phasor-frontend/internal/resilience"
+	"slices"
+	"sync"
+	"time"
+)
+
+// sampleObserver is called with every tile as soon as its sample completes. It may be
+// called concurrently.
+type sampleObserver func(InstanceTileData)
+
+// sample collects the tiles of the requested target according to the sampling parameters.
+// When observe is set, it is called with every tile as soon as it is sampled.
+func (h *FrontendHandler) sample(ctx context.Context, params tilesParams, observe sampleObserver) TilesData {
+	if observe == nil {
+		observe = func(InstanceTileData) {}
+	}
+
+	timeBudget := h.requestBudget
+	if params.Timeout > 0 && params.Timeout < timeBudget {
+		timeBudget = params.Timeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeBudget)
+	defer cancel()
+
+	target := h.selectTarget(params.Target)
+	ctx = withForwarded(ctx, params.Forwarded)
+
+	data := TilesData{
+		Target:  target.Name,
+		Routing: target.routing(params.Canary),
+	}
+
+	switch {
+	case target.Pods != nil:
+		instances, err := h.samplePods(ctx, target, observe)
+		if err != nil {
+			data.Error = err.Error()
+
+			return data
+		}
+
+		data.Instances = instances
+		data.Summary = summarizePods(instances)
+	case params.Mode == samplingModeAdaptive:
+		instances, stopReason := h.sampleAdaptive(ctx, target, params.Patience, params.Budget, observe)
+		data.Instances = instances
+		data.Summary = summarize(instances)
+		data.Summary.StopReason = stopReason
+	case params.Mode == samplingModeSticky:
+		data.Instances = h.sampleSticky(ctx, target, params.Count, params.Clients, observe)
+		data.Summary = summarize(data.Instances)
+		data.Summary.Stickiness = summarizeStickiness(target, data.Instances)
+	default:
+		data.Instances = h.sampleLoadBalanced(ctx, target, params.Count, observe)
+		data.Summary = summarize(data.Instances)
+	}
+
+	if ctx.Err() != nil {
+		data.Summary.TimeBudget = timeBudget
+	}
+
+	if params.Group != "" {
+		data.Summary.groupBy(params.Group, data.Instances)
+	}
+
+	h.recordInventory(target, data.Instances)
+
+	if slices.ContainsFunc(data.Instances, func(instance InstanceTileData) bool { return instance.RateLimited }) {
+		data.rateLimited = true
+
+		return data
+	}
+
+	expected := params.ExpectedSplit
+	if expected == nil {
+		expected = target.ExpectedSplit
+	}
+
+	data.Summary.compareSplit(expected)
+
+	return data
+}
+
+// recordInventory adds the sampled tiles of a target to the instance inventory and
+// flags tiles of instances that restarted.
+func (h *FrontendHandler) recordInventory(target Target, instances []InstanceTileData) {
+	if h.inventory == nil {
+		return
+	}
+
+	now := time.Now()
+
+	for i := range instances {
+		instance := &instances[i]
+		if instance.RateLimited || instance.NotSampled {
+			continue
+		}
+
+		result := h.inventory.Record(inventory.Observation{
+			Target:      target.Name,
+			Hostname:    instance.Info.Hostname,
+			Version:     instance.Info.Version,
+			Uptime:      instance.Info.Uptime,
+			Endpoint:    instance.Endpoint,
+			Failed:      !instance.Reachable,
+			ClockOffset: instance.Skew.Offset,
+			Latency:     instance.Latency,
+			Headers:     capturedMap(instance.Headers),
+			Timestamp:   instance.Info.Timestamp,
+			At:          now,
+		})
+
+		instance.Restarted = result.Restarted
+		instance.Restarts = result.Restarts
+		instance.CrashLooping = result.CrashLooping
+	}
+}
+
+// selectTarget returns the target with the given name, or the first target if none matches.
+func (h *FrontendHandler) selectTarget(name string) Target {
+	for _, target := range h.targets {
+		if target.Name == name {
+			return target
+		}
+	}
+
+	return h.targets[0]
+}
+
+// sampleLoadBalanced samples the load-balanced URL of a target count times.
+func (h *FrontendHandler) sampleLoadBalanced(
+	ctx context.Context,
+	target Target,
+	count int,
+	observe sampleObserver,
+) []InstanceTileData {
+	instances := make([]InstanceTileData, 0, count)
+
+	for range count {
+		instance := notSampledTile()
+		if ctx.Err() == nil {
+			instance = h.sampleOnce(ctx, target)
+		}
+
+		instances = append(instances, instance)
+		observe(instance)
+
+		if instance.RateLimited {
+			break
+		}
+	}
+
+	return instances
+}
+
+// sampleAdaptive samples the load-balanced URL of a target until no new hostname has
+// appeared for patience consecutive samples, or until budget samples were taken.
+// It returns the samples and the reason sampling stopped.
+func (h *FrontendHandler) sampleAdaptive(
+	ctx context.Context,
+	target Target,
+	patience, budget int,
+	observe sampleObserver,
+) ([]InstanceTileData, string) {
+	instances := make([]InstanceTileData, 0, budget)
+	seen := make(map[string]bool)
+	sinceNew := 0
+
+	for len(instances) < budget {
+		if ctx.Err() != nil {
+			return instances, "time budget exhausted"
+		}
+
+		instance := h.sampleOnce(ctx, target)
+		instances = append(instances, instance)
+		observe(instance)
+
+		if instance.RateLimited {
+			return instances, "global rate limit reached"
+		}
+
+		if instance.NotSampled {
+			return instances, "time budget exhausted"
+		}
+
+		if instance.Reachable && !seen[instance.Info.Hostname] {
+			seen[instance.Info.Hostname] = true
+			sinceNew = 0
+
+			continue
+		}
+
+		sinceNew++
+		if sinceNew >= patience {
+			return instances, fmt.Sprintf("no new instance in the last %d samples", patience)
+		}
+	}
+
+	return instances, fmt.Sprintf("sample budget of %d exhausted", budget)
+}
+
+// sampleOnce fetches the instance info from the load-balanced URL of a target.
+func (h *FrontendHandler) sampleOnce(ctx context.Context, target Target) InstanceTileData {
+	return h.fetchTile(ctx, target, target.InstanceURL)
+}
+
+// notSampledTile returns the tile of a sample that did not finish within the time budget.
+func notSampledTile() InstanceTileData {
+	return InstanceTileData{
+		Info: InstanceInfoResponse{
+			Version:   "n/a",
+			Hostname:  "not sampled",
+			Uptime:    "N/A",
+			GoVersion: "N/A",
+			Timestamp: time.Now(),
+		},
+		NotSampled: true,
+	}
+}
+
+// timedInstanceInfo is an instance info response with its headers and the time its request
+// started and completed.
+type timedInstanceInfo struct {
+	info       InstanceInfoResponse
+	header     http.Header
+	start, end time.Time
+}
+
+// fetchTile fetches the instance info from instanceURL through the resilience policy of
+// the target and estimates the clock skew of the instance that answered. Only the
+// answering request makes up the sample; retries and hedged requests are merely counted.
+func (h *FrontendHandler) fetchTile(ctx context.Context, target Target, instanceURL string) InstanceTileData {
+	release, allowed, err := h.acquireSample(ctx)
+	if !allowed {
+		h.metrics.samples.With("rate_limited").Inc()
+
+		return InstanceTileData{Info: errorInstanceInfo(), RateLimited: true}
+	}
+
+	if err != nil {
+		h.metrics.samples.With("not_sampled").Inc()
+
+		return notSampledTile()
+	}
+
+	defer release()
+
+	response, outcome, err := resilience.Do(ctx, target.Policy, func(ctx context.Context) (timedInstanceInfo, error) {
+		start := time.Now()
+		info, header, err := h.fetchInstanceInfo(ctx, target, instanceURL)
+
+		return timedInstanceInfo{info: info, header: header, start: start, end: time.Now()}, err
+	})
+	if err != nil && ctx.Err() != nil {
+		h.metrics.samples.With("not_sampled").Inc()
+
+		tile := notSampledTile()
+		tile.Retries = outcome.Retries
+		tile.Hedges = outcome.Hedges
+
+		return tile
+	}
+
+	if err != nil {
+		result := "error"
+		if outcome.ShortCircuited {
+			result = "short_circuited"
+		}
+
+		h.metrics.samples.With(result).Inc()
+
+		return InstanceTileData{
+			Info:           errorInstanceInfo(),
+			Retries:        outcome.Retries,
+			Hedges:         outcome.Hedges,
+			ShortCircuited: outcome.ShortCircuited,
+		}
+	}
+
+	h.metrics.samples.With("ok").Inc()
+
+	skew := estimateSkew(response.info.Timestamp, response.start, response.end)
+
+	return InstanceTileData{
+		Info:      response.info,
+		Pod:       parsePodName(h.hostnamePatterns, response.info.Hostname),
+		Reachable: true,
+		Latency:   response.end.Sub(response.start),
+		Skew:      skew,
+		Skewed:    skew.Exceeds(h.skewThreshold),
+		Retries:   outcome.Retries,
+		Hedges:    outcome.Hedges,
+		Headers:   target.captured(response.header),
+	}
+}
+
+// samplePods samples every pod of a target once, concurrently.
+func (h *FrontendHandler) samplePods(
+	ctx context.Context,
+	target Target,
+	observe sampleObserver,
+) ([]InstanceTileData, error) {
+	endpoints, err := target.Pods.Endpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover pods: %w", err)
+	}
+
+	instances := make([]InstanceTileData, len(endpoints))
+
+	var waitGroup sync.WaitGroup
+
+	for i, endpoint := range endpoints {
+		waitGroup.Go(func() {
+			instance := h.fetchTile(ctx, target, target.podURL(endpoint))
+			instance.Endpoint = endpoint.Address()
+			instances[i] = instance
+			observe(instance)
+		})
+	}
+
+	waitGroup.Wait()
+
+	return instances, nil
+}
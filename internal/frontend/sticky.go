@@ -0,0 +1,169 @@
+package frontend
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/cookiejar"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultClients = 3
+	maxClients     = 10
+)
+
+type cookieJarKey struct{}
+
+// withCookieJar makes the instance info requests of ctx send and store cookies in jar,
+// like a browser that keeps its session.
+func withCookieJar(ctx context.Context, jar http.CookieJar) context.Context {
+	return context.WithValue(ctx, cookieJarKey{}, jar)
+}
+
+// cookieJar returns the cookie jar of ctx, or nil when requests are sent without cookies.
+func cookieJar(ctx context.Context) http.CookieJar {
+	jar, _ := ctx.Value(cookieJarKey{}).(http.CookieJar)
+
+	return jar
+}
+
+// Stickiness reports whether simulated clients kept landing on the same instance.
+type Stickiness struct {
+	// Affinity describes how clients identify their session, by cookie or affinity header.
+	Affinity string
+	Clients  []ClientStickiness
+	// StickyClients counts the clients whose samples all landed on the same instance.
+	StickyClients int
+	// Ratio is the share of answered samples that landed on the main instance of their client.
+	Ratio float64
+}
+
+// ClientStickiness describes where the samples of a single simulated client landed.
+type ClientStickiness struct {
+	Client  int
+	Samples int
+	// Hostnames lists the instances that answered the client, the main instance first.
+	Hostnames []string
+	Sticky    bool
+}
+
+// RatioPercent returns the stickiness ratio as a percentage.
+func (s *Stickiness) RatioPercent() float64 {
+	return s.Ratio * percent
+}
+
+// sampleSticky simulates clients that each take count samples within one session, kept
+// by a cookie jar or by an affinity header with a value per client.
+func (h *FrontendHandler) sampleSticky(
+	ctx context.Context,
+	target Target,
+	count int,
+	clients int,
+	observe sampleObserver,
+) []InstanceTileData {
+	instances := make([]InstanceTileData, 0, count*clients)
+	session := strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	for client := 1; client <= clients; client++ {
+		clientCtx := target.clientSession(ctx, fmt.Sprintf("phasor-%s-%d", session, client))
+
+		sampled := h.sampleLoadBalanced(clientCtx, target, count, func(tile InstanceTileData) {
+			tile.Client = client
+			observe(tile)
+		})
+
+		for i := range sampled {
+			sampled[i].Client = client
+		}
+
+		instances = append(instances, sampled...)
+
+		if slices.ContainsFunc(sampled, func(tile InstanceTileData) bool { return tile.RateLimited }) {
+			break
+		}
+	}
+
+	return instances
+}
+
+// clientSession returns a context whose requests belong to the session of a simulated
+// client: a fresh cookie jar, or the affinity header of the target set to session.
+func (t Target) clientSession(ctx context.Context, session string) context.Context {
+	if t.AffinityHeader == "" {
+		jar, _ := cookiejar.New(nil) // Never fails without options.
+
+		return withCookieJar(ctx, jar)
+	}
+
+	forwarded, _ := ctx.Value(forwardedKey{}).(http.Header)
+
+	header := forwarded.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	header.Set(t.AffinityHeader, session)
+
+	return withForwarded(ctx, header)
+}
+
+// summarizeStickiness reports for every simulated client whether its answered samples
+// all landed on the same instance.
+func summarizeStickiness(target Target, instances []InstanceTileData) *Stickiness {
+	stickiness := &Stickiness{Affinity: "cookie jar"}
+	if target.AffinityHeader != "" {
+		stickiness.Affinity = "header " + target.AffinityHeader
+	}
+
+	counts := make(map[int]map[string]int)
+	answered, onMain := 0, 0
+
+	for _, instance := range instances {
+		if !instance.Reachable {
+			continue
+		}
+
+		if counts[instance.Client] == nil {
+			counts[instance.Client] = make(map[string]int)
+		}
+
+		counts[instance.Client][instance.Info.Hostname]++
+	}
+
+	for _, client := range slices.Sorted(maps.Keys(counts)) {
+		hostCounts := counts[client]
+		hostnames := slices.SortedFunc(maps.Keys(hostCounts), func(a, b string) int {
+			return cmp.Or(cmp.Compare(hostCounts[b], hostCounts[a]), cmp.Compare(a, b))
+		})
+
+		samples := 0
+		for _, count := range hostCounts {
+			samples += count
+		}
+
+		answered += samples
+		onMain += hostCounts[hostnames[0]]
+
+		stickiness.Clients = append(stickiness.Clients, ClientStickiness{
+			Client:    client,
+			Samples:   samples,
+			Hostnames: hostnames,
+			Sticky:    len(hostnames) == 1,
+		})
+
+		if len(hostnames) == 1 {
+			stickiness.StickyClients++
+		}
+	}
+
+	if answered > 0 {
+		stickiness.Ratio = float64(onMain) / float64(answered)
+	}
+
+	return stickiness
+}
@@ -0,0 +1,156 @@
+package frontend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// tilesContainerID is the id of the element on the index page that holds the tiles.
+	tilesContainerID = "tiles-container"
+	// streamDelimiter ends every fragment of a streamed response, so the client only
+	// swaps complete fragments. The tiles-stream extension on the index page splits on it.
+	streamDelimiter = "<!-- end of fragment -->"
+	// streamHeader marks streamed responses for the client.
+	streamHeader = "X-Tiles-Stream"
+)
+
+type flusherKey struct{}
+
+// KeepFlusher makes the flusher of the response writer available to streaming handlers
+// behind middleware whose response writer wrappers do not implement http.Flusher. It must
+// be installed outside of such middleware.
+func KeepFlusher(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if flusher, ok := writer.(http.Flusher); ok {
+			req = req.WithContext(context.WithValue(req.Context(), flusherKey{}, flusher))
+		}
+
+		next.ServeHTTP(writer, req)
+	})
+}
+
+// tileStream writes htmx out-of-band fragments to a chunked response, safe for concurrent use.
+type tileStream struct {
+	mu      sync.Mutex
+	writer  io.Writer
+	flusher http.Flusher
+	err     error
+}
+
+// fragment writes a fragment and flushes it to the client. After the first write error,
+// further fragments are dropped.
+func (s *tileStream) fragment(write func(io.Writer) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return
+	}
+
+	s.err = write(s.writer)
+	if s.err == nil {
+		_, s.err = io.WriteString(s.writer, streamDelimiter+"\n")
+	}
+
+	s.flusher.Flush()
+}
+
+// streamTiles answers a tile request progressively: every tile is appended to the tiles
+// container as soon as its sample completes, and the fully rendered tiles with their
+// summary replace the container at the end. Streamed runs are not shared with other requests.
+func (h *FrontendHandler) streamTiles(writer http.ResponseWriter, req *http.Request, params tilesParams) {
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		flusher, ok = req.Context().Value(flusherKey{}).(http.Flusher)
+	}
+
+	if !ok {
+		http.Error(writer, "streaming is not supported", http.StatusInternalServerError)
+
+		return
+	}
+
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.Header().Set(streamHeader, "true")
+
+	refresh := h.autoRefresh(writer, params)
+
+	writer.WriteHeader(http.StatusOK)
+
+	stream := &tileStream{writer: writer, flusher: flusher}
+
+	replaceContainer := fmt.Sprintf(`id="%s" hx-swap-oob="innerHTML"`, tilesContainerID)
+	appendToContainer := fmt.Sprintf(`hx-swap-oob="beforeend:#%s"`, tilesContainerID)
+
+	// Clear the container, so streamed tiles are not appended to the previous ones.
+	stream.fragment(func(w io.Writer) error {
+		return oobFragment(w, replaceContainer, func() error { return nil })
+	})
+
+	// Streamed tiles are colored and filtered as they arrive; the final render reserves colors
+	// for all of them and settles the outdated filter, which needs the newest version.
+	coloring := h.newTileColoring(params.Colors)
+
+	data := h.sample(req.Context(), params, func(tile InstanceTileData) {
+		if tile.RateLimited || !params.Filter.matches(tile, "") {
+			return
+		}
+
+		h.decorateTile(&tile, coloring, time.Now())
+
+		stream.fragment(func(w io.Writer) error {
+			return oobFragment(w, appendToContainer, func() error {
+				return h.templates.ExecuteTemplate(w, "tile", tile) //nolint:wrapcheck // Wrapped by oobFragment.
+			})
+		})
+	})
+
+	sampledAt := time.Now()
+
+	if data.rateLimited {
+		data.Error = "global rate limit reached, sampling stopped early"
+	} else {
+		h.cache.store(params.viewKey(data.Target), data, sampledAt)
+	}
+
+	data.colors = params.Colors
+	data.filter = params.Filter
+	data.order = params.Sort
+	data.Refresh = refresh
+	data.SampledAt = sampledAt
+	data.Freshness = freshness(sampledAt, sourceFresh)
+
+	stream.fragment(func(w io.Writer) error {
+		return oobFragment(w, replaceContainer, func() error {
+			return h.executeTiles(w, data)
+		})
+	})
+}
+
+// oobFragment wraps the content written by execute in an element with the given
+// attributes, which tell htmx where to swap it out of band.
+func oobFragment(w io.Writer, attributes string, execute func() error) error {
+	_, err := fmt.Fprintf(w, "<div %s>", attributes)
+	if err != nil {
+		return fmt.Errorf("failed to write fragment: %w", err)
+	}
+
+	err = execute()
+	if err != nil {
+		return fmt.Errorf("failed to render fragment: %w", err)
+	}
+
+	_, err = io.WriteString(w, "</div>")
+	if err != nil {
+		return fmt.Errorf("failed to write fragment: %w", err)
+	}
+
+	return nil
+}
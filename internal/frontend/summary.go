@@ -0,0 +1,311 @@
+package frontend
+
+import (
+	"cmp"
+	"path"
+	"phasor-frontend/internal/stats"
+	"slices"
+	"strings"
+	"time"
+)
+
+const percent = 100
+
+// Summary describes the sampled population shown above the tiles.
+type Summary struct {
+	Samples int
+	Errors  int
+	// NotSampled counts samples that did not finish within the time budget of the request.
+	// They are not part of Samples.
+	NotSampled int
+	// TimeBudget is set when the time budget of the request ran out.
+	TimeBudget time.Duration
+	// Retries, Hedges and ShortCircuited count the work done by the resilience policy.
+	// Only the answering request of a sample contributes to the shares.
+	Retries        int
+	Hedges         int
+	ShortCircuited int
+	Coverage       stats.Coverage
+	// Exact is set when the instance count is known, e.g. from pod discovery.
+	Exact bool
+	// StopReason explains why adaptive sampling stopped.
+	StopReason string
+	// Versions lists the observed share of every version, with 95% confidence intervals.
+	Versions []VersionShare
+	// Split compares the version shares against an expected split, when one is declared.
+	Split *stats.GoodnessOfFit
+	// GroupBy is the captured response header the samples are grouped by, Groups lists
+	// the groups by descending size.
+	GroupBy string
+	Groups  []Group
+	// Revisions groups the answered samples by the ReplicaSet revision of their pods.
+	Revisions []RevisionShare
+	// Stickiness reports session affinity in sticky sampling.
+	Stickiness *Stickiness
+}
+
+// missingGroupValue is the group of samples without the grouping header.
+const missingGroupValue = "(missing)"
+
+// Group holds the samples that share a value of the grouping header.
+type Group struct {
+	Value string
+	Count int
+	// Hostnames and Versions list the instances that answered the samples of the group.
+	Hostnames []string
+	Versions  []string
+}
+
+// VersionShare is the observed traffic share of a single version.
+type VersionShare struct {
+	// Version is the version, or the pattern of the expected split its versions were merged into.
+	Version  string
+	Count    int
+	Share    float64
+	Interval stats.Interval
+	// Expected is the expected share, valid when HasExpected is set.
+	Expected    float64
+	HasExpected bool
+	// Deviates is set when the expected share lies outside the confidence interval.
+	Deviates bool
+}
+
+// groupBy groups the answered samples by the value of a captured response header, to
+// correlate routing decisions with the instances that answered.
+func (s *Summary) groupBy(name string, instances []InstanceTileData) {
+	s.GroupBy = name
+	s.Groups = nil
+
+	groups := make(map[string]*Group)
+
+	for _, instance := range instances {
+		if !instance.Reachable {
+			continue
+		}
+
+		value, found := instance.header(name)
+		if !found {
+			value = missingGroupValue
+		}
+
+		group, found := groups[value]
+		if !found {
+			group = &Group{Value: value}
+			groups[value] = group
+		}
+
+		group.Count++
+
+		if !slices.Contains(group.Hostnames, instance.Info.Hostname) {
+			group.Hostnames = append(group.Hostnames, instance.Info.Hostname)
+		}
+
+		if !slices.Contains(group.Versions, instance.Info.Version) {
+			group.Versions = append(group.Versions, instance.Info.Version)
+		}
+	}
+
+	for _, group := range groups {
+		slices.Sort(group.Hostnames)
+		slices.Sort(group.Versions)
+		s.Groups = append(s.Groups, *group)
+	}
+
+	slices.SortFunc(s.Groups, func(a, b Group) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Value, b.Value))
+	})
+}
+
+// Planned returns the number of samples the request set out to take.
+func (s *Summary) Planned() int {
+	return s.Samples + s.NotSampled
+}
+
+// Answered returns the number of samples an instance answered.
+func (s *Summary) Answered() int {
+	return s.Samples - s.Errors
+}
+
+// ConfidencePercent returns the coverage confidence as a whole percentage, rounded down.
+func (s *Summary) ConfidencePercent() int {
+	return int(s.Coverage.Confidence * percent)
+}
+
+// SharePercent returns the observed share as a percentage.
+func (v VersionShare) SharePercent() float64 {
+	return v.Share * percent
+}
+
+// ExpectedPercent returns the expected share as a percentage.
+func (v VersionShare) ExpectedPercent() float64 {
+	return v.Expected * percent
+}
+
+// LowPercent returns the lower bound of the confidence interval as a percentage.
+func (v VersionShare) LowPercent() float64 {
+	return v.Interval.Low * percent
+}
+
+// HighPercent returns the upper bound of the confidence interval as a percentage.
+func (v VersionShare) HighPercent() float64 {
+	return v.Interval.High * percent
+}
+
+// summarize builds the summary of sampled tiles. Failed samples are counted as errors
+// and do not contribute to the instance estimate or the version shares. Without any
+// answered sample there is nothing to estimate, and the coverage stays zero.
+func summarize(instances []InstanceTileData) *Summary {
+	summary := &Summary{}
+
+	hostCounts := make(map[string]int)
+	versionCounts := make(map[string]int)
+
+	for _, instance := range instances {
+		summary.Retries += instance.Retries
+		summary.Hedges += instance.Hedges
+
+		if instance.NotSampled {
+			summary.NotSampled++
+
+			continue
+		}
+
+		summary.Samples++
+
+		if instance.ShortCircuited {
+			summary.ShortCircuited++
+		}
+
+		if !instance.Reachable {
+			summary.Errors++
+
+			continue
+		}
+
+		hostCounts[instance.Info.Hostname]++
+		versionCounts[instance.Info.Version]++
+	}
+
+	if summary.Answered() > 0 {
+		counts := make([]int, 0, len(hostCounts))
+		for _, count := range hostCounts {
+			counts = append(counts, count)
+		}
+
+		summary.Coverage = stats.EstimateCoverage(counts)
+	}
+
+	summary.Versions = versionShares(versionCounts, summary.Answered())
+	summary.Revisions = revisionShares(instances)
+
+	return summary
+}
+
+// summarizePods builds the summary of a pod target, where the instance count is known.
+func summarizePods(instances []InstanceTileData) *Summary {
+	summary := summarize(instances)
+	summary.Exact = true
+	summary.Coverage.Estimated = len(instances)
+	summary.Coverage.Confidence = 1
+
+	return summary
+}
+
+// versionShares computes the share and confidence interval of every version.
+func versionShares(versionCounts map[string]int, total int) []VersionShare {
+	shares := make([]VersionShare, 0, len(versionCounts))
+
+	for version, count := range versionCounts {
+		share := VersionShare{
+			Version:  version,
+			Count:    count,
+			Interval: stats.WilsonInterval(count, total, stats.Z95),
+		}
+
+		if total > 0 {
+			share.Share = float64(count) / float64(total)
+		}
+
+		shares = append(shares, share)
+	}
+
+	slices.SortFunc(shares, func(a, b VersionShare) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return shares
+}
+
+// compareSplit compares the observed version shares against an expected split.
+// Keys are versions or glob patterns such as 1.2.*; the versions matched by a pattern
+// are merged into one share named by the pattern. Keys that were never observed are
+// added with a count of zero.
+func (s *Summary) compareSplit(expected map[string]float64) {
+	if len(expected) == 0 {
+		return
+	}
+
+	var weightSum float64
+	for _, weight := range expected {
+		weightSum += weight
+	}
+
+	if weightSum == 0 {
+		return
+	}
+
+	counts := make(map[string]int, len(expected))
+	for key := range expected {
+		counts[key] = 0
+	}
+
+	for _, share := range s.Versions {
+		counts[splitKey(share.Version, expected)] += share.Count
+	}
+
+	total := s.Answered()
+	s.Versions = versionShares(counts, total)
+
+	observed := make([]int, len(s.Versions))
+	shares := make([]float64, len(s.Versions))
+
+	for i := range s.Versions {
+		share := &s.Versions[i]
+		share.Expected = expected[share.Version] / weightSum
+		share.HasExpected = true
+		share.Deviates = total > 0 && !share.Interval.Contains(share.Expected)
+		observed[i] = share.Count
+		shares[i] = share.Expected
+	}
+
+	fit := stats.ChiSquareTest(observed, shares)
+	s.Split = &fit
+}
+
+// splitKey returns the key of the expected split a version counts towards: the version
+// itself when it is a key, otherwise the longest pattern that matches it. Versions that
+// match no key count on their own.
+func splitKey(version string, expected map[string]float64) string {
+	if _, found := expected[version]; found {
+		return version
+	}
+
+	best := version
+
+	for key := range expected {
+		if !strings.ContainsAny(key, "*?[") {
+			continue
+		}
+
+		matched, err := path.Match(key, version)
+		if err != nil || !matched {
+			continue
+		}
+
+		if best == version || len(key) > len(best) || (len(key) == len(best) && key < best) {
+			best = key
+		}
+	}
+
+	return best
+}
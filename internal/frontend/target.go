@@ -0,0 +1,83 @@
+package frontend
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"phasor-frontend/internal/auth"
+	"phasor-frontend/internal/discovery"
+	"phasor-frontend/internal/resilience"
+)
+
+const (
+	defaultPodScheme = "http"
+	defaultPodPath   = "/instance/info"
+)
+
+// PodSource enumerates the pods of a target so they can be sampled directly.
+type PodSource interface {
+	Endpoints(ctx context.Context) ([]discovery.Endpoint, error)
+}
+
+// Target is a backend that the frontend samples.
+type Target struct {
+	// Name identifies the target in the UI and in the target query parameter.
+	Name string
+	// InstanceURL is the load-balanced instance info URL of the target.
+	InstanceURL string
+	// Pods, when set, enumerates pods that are sampled directly instead of InstanceURL.
+	Pods PodSource
+	// PodScheme is the URL scheme used to reach pods (default http).
+	PodScheme string
+	// PodPath is the instance info path on the pods (default /instance/info).
+	PodPath string
+	// ExpectedSplit maps versions or glob patterns of versions, e.g. 1.2.*, to their
+	// expected traffic share. Shares are relative weights, typically percentages.
+	ExpectedSplit map[string]float64
+	// Policy applies retries, hedging and circuit breaking to samples of the target.
+	// Samples are taken once when it is nil.
+	Policy *resilience.Policy
+	// TLS secures connections to the target. Connections use the default TLS settings when it is nil.
+	TLS *tls.Config
+	// Headers are sent with every instance info request of the target.
+	Headers http.Header
+	// ForwardHeaders and ForwardCookies allowlist the headers and cookies of the browser
+	// request that are forwarded to the target, e.g. for header-based routing.
+	ForwardHeaders []string
+	ForwardCookies []string
+	// Canary routes samples to the canary of the target when the user asks for it.
+	Canary CanaryRoute
+	// AffinityHeader identifies the session of a simulated client in sticky sampling. Sessions
+	// are kept by cookies when it is empty.
+	AffinityHeader string
+	// PinHeader asks the routing layer to route a request to the hostname in its value.
+	// Hunting for a host is only offered when it is set.
+	PinHeader string
+	// CaptureHeaders lists the response headers stored on every sample, e.g. headers that
+	// tell which path through the ingress or mesh a request took.
+	CaptureHeaders []string
+	// EditRequest adds credentials to every instance info request of the target, when set.
+	EditRequest auth.RequestEditor
+
+	client *http.Client
+}
+
+// podURL returns the instance info URL of a single pod of the target.
+func (t Target) podURL(endpoint discovery.Endpoint) string {
+	podURL := url.URL{
+		Scheme: t.PodScheme,
+		Host:   endpoint.Address(),
+		Path:   t.PodPath,
+	}
+
+	if podURL.Scheme == "" {
+		podURL.Scheme = defaultPodScheme
+	}
+
+	if podURL.Path == "" {
+		podURL.Path = defaultPodPath
+	}
+
+	return podURL.String()
+}
@@ -2,27 +2,113 @@ package health
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"net/url"
+	"phasor-frontend/internal/auth"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/monkescience/vital"
 )
 
 const (
-	healthCheckTimeout = 2 * time.Second
+	healthCheckTimeout  = 2 * time.Second
+	healthCheckInterval = 10 * time.Second
 )
 
+// FailurePolicy controls how a failing backend affects the readiness of the frontend.
+type FailurePolicy string
+
+const (
+	// FailurePolicyNotReady reports the frontend as not ready while the backend is failing.
+	FailurePolicyNotReady FailurePolicy = "not_ready"
+	// FailurePolicyDegraded keeps the frontend ready and only reports the backend as degraded.
+	FailurePolicyDegraded FailurePolicy = "degraded"
+)
+
+// CheckerOption configures a BackendChecker.
+type CheckerOption func(*BackendChecker)
+
+// WithInterval sets how often the backend is checked in the background.
+func WithInterval(interval time.Duration) CheckerOption {
+	return func(c *BackendChecker) {
+		if interval > 0 {
+			c.interval = interval
+		}
+	}
+}
+
+// WithTimeout sets the timeout of a single backend health request.
+func WithTimeout(timeout time.Duration) CheckerOption {
+	return func(c *BackendChecker) {
+		if timeout > 0 {
+			c.client.Timeout = timeout
+		}
+	}
+}
+
+// WithTLSConfig secures health requests with tlsConfig instead of the default TLS settings.
+// The proxy, dial and connection pool settings of the default transport are kept.
+func WithTLSConfig(tlsConfig *tls.Config) CheckerOption {
+	return func(c *BackendChecker) {
+		if tlsConfig == nil {
+			return
+		}
+
+		transport := &http.Transport{}
+		if defaultTransport, ok := http.DefaultTransport.(*http.Transport); ok {
+			transport = defaultTransport.Clone()
+		}
+
+		transport.TLSClientConfig = tlsConfig
+		c.client.Transport = transport
+	}
+}
+
+// WithRequestEditor adds credentials to every health request.
+func WithRequestEditor(editRequest auth.RequestEditor) CheckerOption {
+	return func(c *BackendChecker) { c.editRequest = editRequest }
+}
+
+// WithFailurePolicy sets how a failing backend is reported.
+func WithFailurePolicy(policy FailurePolicy) CheckerOption {
+	return func(c *BackendChecker) {
+		if policy != "" {
+			c.policy = policy
+		}
+	}
+}
+
+// checkResult holds the outcome of the most recent backend health checks.
+type checkResult struct {
+	checked             bool
+	healthy             bool
+	message             string
+	lastSuccess         time.Time
+	consecutiveFailures int
+	latency             time.Duration
+}
+
 // BackendChecker checks the health of the backend service.
+// Results are refreshed in the background and served from cache.
 type BackendChecker struct {
-	client    *http.Client
-	healthURL string
+	client      *http.Client
+	editRequest auth.RequestEditor
+	healthURL   string
+	interval    time.Duration
+	policy      FailurePolicy
+
+	refreshMu sync.Mutex
+	mu        sync.RWMutex
+	result    checkResult
 }
 
 // NewBackendChecker creates a new backend health checker from the backend URL.
 // It derives the health endpoint by using the base URL with /health/ready path.
-func NewBackendChecker(backendURL string) (*BackendChecker, error) {
+func NewBackendChecker(backendURL string, opts ...CheckerOption) (*BackendChecker, error) {
 	parsed, err := url.Parse(backendURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse backend URL: %w", err)
@@ -30,12 +116,20 @@ func NewBackendChecker(backendURL string) (*BackendChecker, error) {
 
 	healthURL := fmt.Sprintf("%s://%s/health/ready", parsed.Scheme, parsed.Host)
 
-	return &BackendChecker{
+	checker := &BackendChecker{
 		client: &http.Client{
 			Timeout: healthCheckTimeout,
 		},
 		healthURL: healthURL,
-	}, nil
+		interval:  healthCheckInterval,
+		policy:    FailurePolicyNotReady,
+	}
+
+	for _, opt := range opts {
+		opt(checker)
+	}
+
+	return checker, nil
 }
 
 // Name returns the name of this health check.
@@ -43,16 +137,114 @@ func (c *BackendChecker) Name() string {
 	return "backend"
 }
 
-// Check performs a health check against the backend service.
+// Start refreshes the backend health in the background until the context is canceled.
+func (c *BackendChecker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			c.refresh(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Check reports the cached backend health. Until the first background refresh
+// has completed, the backend is checked synchronously.
 func (c *BackendChecker) Check(ctx context.Context) (vital.Status, string) {
+	c.mu.RLock()
+	result := c.result
+	c.mu.RUnlock()
+
+	if !result.checked {
+		result = c.refreshIfUnchecked(ctx)
+	}
+
+	detail := formatDetail(result)
+
+	switch {
+	case result.healthy:
+		return vital.StatusOK, detail
+	case c.policy == FailurePolicyDegraded:
+		return vital.StatusOK, "degraded: " + result.message + "; " + detail
+	default:
+		return vital.StatusError, result.message + "; " + detail
+	}
+}
+
+// refreshIfUnchecked probes the backend unless a concurrent refresh already stored a result.
+func (c *BackendChecker) refreshIfUnchecked(ctx context.Context) checkResult {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	c.mu.RLock()
+	result := c.result
+	c.mu.RUnlock()
+
+	if result.checked {
+		return result
+	}
+
+	return c.probeAndStore(ctx)
+}
+
+// refresh probes the backend once and stores the result.
+func (c *BackendChecker) refresh(ctx context.Context) checkResult {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	return c.probeAndStore(ctx)
+}
+
+// probeAndStore probes the backend and folds the outcome into the cached result.
+// Callers must hold refreshMu.
+func (c *BackendChecker) probeAndStore(ctx context.Context) checkResult {
+	start := time.Now()
+	message := c.probe(ctx)
+	latency := time.Since(start)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.result.checked = true
+	c.result.healthy = message == ""
+	c.result.message = message
+	c.result.latency = latency
+
+	if c.result.healthy {
+		c.result.lastSuccess = start
+		c.result.consecutiveFailures = 0
+	} else {
+		c.result.consecutiveFailures++
+	}
+
+	return c.result
+}
+
+// probe performs a single request against the backend health endpoint.
+// It returns an empty message when the backend is healthy.
+func (c *BackendChecker) probe(ctx context.Context) string {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.healthURL, nil)
 	if err != nil {
-		return vital.StatusError, fmt.Sprintf("failed to create request: %v", err)
+		return fmt.Sprintf("failed to create request: %v", err)
+	}
+
+	if c.editRequest != nil {
+		err = c.editRequest(ctx, req)
+		if err != nil {
+			return fmt.Sprintf("failed to authenticate request: %v", err)
+		}
 	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return vital.StatusError, fmt.Sprintf("failed to reach backend: %v", err)
+		return fmt.Sprintf("failed to reach backend: %v", err)
 	}
 
 	defer func() {
@@ -60,8 +252,24 @@ func (c *BackendChecker) Check(ctx context.Context) (vital.Status, string) {
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return vital.StatusError, fmt.Sprintf("backend returned status %d", resp.StatusCode)
+		return fmt.Sprintf("backend returned status %d", resp.StatusCode)
+	}
+
+	return ""
+}
+
+// formatDetail renders the last success time, consecutive failures and latency of a result.
+func formatDetail(result checkResult) string {
+	lastSuccess := "never"
+	if !result.lastSuccess.IsZero() {
+		lastSuccess = result.lastSuccess.UTC().Format(time.RFC3339)
 	}
 
-	return vital.StatusOK, ""
+	var detail strings.Builder
+
+	fmt.Fprintf(&detail, "last_success=%s", lastSuccess)
+	fmt.Fprintf(&detail, " consecutive_failures=%d", result.consecutiveFailures)
+	fmt.Fprintf(&detail, " latency=%s", result.latency.Round(time.Millisecond))
+
+	return detail.String()
 }
@@ -0,0 +1,73 @@
+package inventory
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// historySize is the number of samples kept per instance.
+const historySize = 50
+
+// Sample is a single sample in the history of an instance.
+type Sample struct {
+	Target  string `json:"target"`
+	Version string `json:"version"`
+	Uptime  string `json:"uptime"`
+	// Latency is the duration of the answering request, in nanoseconds.
+	Latency time.Duration `json:"latency"`
+	// ClockOffset is the estimated clock offset of the instance, in nanoseconds.
+	ClockOffset time.Duration     `json:"clock_offset"`
+	Failed      bool              `json:"failed"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	At          time.Time         `json:"at"`
+}
+
+// History returns the recent samples of a hostname across all targets, oldest first.
+func (s *Store) History(hostname string) []Sample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var samples []Sample
+
+	for key, history := range s.histories {
+		if key.hostname == hostname {
+			samples = append(samples, history...)
+		}
+	}
+
+	slices.SortStableFunc(samples, func(a, b Sample) int {
+		return cmp.Or(a.At.Compare(b.At), cmp.Compare(a.Target, b.Target))
+	})
+
+	return samples
+}
+
+// appendSample adds an observation to the history of its instance, dropping the oldest
+// sample when full.
+func (s *Store) appendSample(key instanceKey, version string, obs Observation) {
+	history := s.histories[key]
+	if len(history) == historySize {
+		history = slices.Delete(history, 0, 1)
+	}
+
+	s.histories[key] = append(history, Sample{
+		Target:      obs.Target,
+		Version:     version,
+		Uptime:      obs.Uptime,
+		Latency:     obs.Latency.Round(time.Microsecond),
+		ClockOffset: obs.ClockOffset.Round(time.Millisecond),
+		Failed:      obs.Failed,
+		Headers:     obs.Headers,
+		At:          obs.At,
+	})
+}
+
+// expireHistories forgets the samples of instances not seen within the expiry threshold.
+func (s *Store) expireHistories(now time.Time) {
+	for key, history := range s.histories {
+		if now.Sub(history[len(history)-1].At) > s.expireAfter {
+			delete(s.histories, key)
+		}
+	}
+}
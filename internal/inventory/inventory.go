@@ -0,0 +1,256 @@
+// Package inventory keeps track of every backend instance the frontend has sampled.
+package inventory
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	defaultStaleAfter  = 5 * time.Minute
+	defaultExpireAfter = time.Hour
+	unknownVersion     = "unknown"
+)
+
+// State describes how recently an instance was seen.
+type State string
+
+const (
+	// StateActive marks instances seen within the stale threshold.
+	StateActive State = "active"
+	// StateStale marks instances not seen within the stale threshold. They are
+	// removed once the expiry threshold passes as well.
+	StateStale State = "stale"
+)
+
+// Observation is the outcome of a single sample of a target.
+type Observation struct {
+	Target   string
+	Hostname string
+	Version  string
+	Uptime   string
+	// Endpoint is the pod address when the pod was sampled directly. It attributes
+	// failed samples to the instance last seen at that address.
+	Endpoint string
+	Failed   bool
+	// ClockOffset is the estimated clock offset of the instance.
+	ClockOffset time.Duration
+	// Latency is the duration of the answering request.
+	Latency time.Duration
+	// Headers are the captured response headers of the sample.
+	Headers map[string]string
+	// Timestamp is the time the instance reported with the uptime, on its own clock.
+	Timestamp time.Time
+	At        time.Time
+}
+
+// Entry is the inventory record of one (hostname, version) pair of a target.
+type Entry struct {
+	Target     string    `json:"target"`
+	Hostname   string    `json:"hostname"`
+	Version    string    `json:"version"`
+	State      State     `json:"state"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+	Samples    int       `json:"samples"`
+	LastUptime string    `json:"last_uptime"`
+	Errors     int       `json:"errors"`
+	Restarts   int       `json:"restarts"`
+	// ClockOffset is the clock offset estimated from the last sample, in nanoseconds.
+	ClockOffset time.Duration `json:"clock_offset"`
+}
+
+type entryKey struct {
+	target   string
+	hostname string
+	version  string
+}
+
+type endpointKey struct {
+	target   string
+	endpoint string
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithStaleAfter sets after how long without a sample an instance is marked stale.
+func WithStaleAfter(d time.Duration) Option {
+	return func(s *Store) {
+		if d > 0 {
+			s.staleAfter = d
+		}
+	}
+}
+
+// WithExpireAfter sets after how long without a sample an instance is removed.
+func WithExpireAfter(d time.Duration) Option {
+	return func(s *Store) {
+		if d > 0 {
+			s.expireAfter = d
+		}
+	}
+}
+
+// Store is an in-memory inventory of sampled instances, safe for concurrent use.
+type Store struct {
+	staleAfter         time.Duration
+	expireAfter        time.Duration
+	restartWindow      time.Duration
+	crashLoopThreshold int
+
+	mu        sync.Mutex
+	entries   map[entryKey]*Entry
+	endpoints map[endpointKey]entryKey
+	uptimes   map[instanceKey]*uptimeState
+	histories map[instanceKey][]Sample
+	events    []Event
+}
+
+// NewStore creates an empty inventory.
+func NewStore(opts ...Option) *Store {
+	store := &Store{
+		staleAfter:         defaultStaleAfter,
+		expireAfter:        defaultExpireAfter,
+		restartWindow:      defaultRestartWindow,
+		crashLoopThreshold: defaultCrashLoopThreshold,
+		entries:            make(map[entryKey]*Entry),
+		endpoints:          make(map[endpointKey]entryKey),
+		uptimes:            make(map[instanceKey]*uptimeState),
+		histories:          make(map[instanceKey][]Sample),
+	}
+
+	for _, opt := range opts {
+		opt(store)
+	}
+
+	return store
+}
+
+// Record adds an observation to the inventory. Failed observations are only recorded
+// when they can be attributed to an instance through their endpoint. The result tells
+// whether the observation revealed a restart of the instance.
+func (s *Store) Record(obs Observation) RecordResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire(obs.At)
+
+	if obs.Failed {
+		s.recordFailure(obs)
+
+		return RecordResult{}
+	}
+
+	key := entryKey{target: obs.Target, hostname: obs.Hostname, version: obs.Version}
+
+	entry := s.entry(key, obs.At)
+	entry.LastSeen = obs.At
+	entry.Samples++
+	entry.LastUptime = obs.Uptime
+	entry.ClockOffset = obs.ClockOffset.Round(time.Millisecond)
+
+	if obs.Endpoint != "" {
+		s.endpoints[endpointKey{target: obs.Target, endpoint: obs.Endpoint}] = key
+	}
+
+	s.appendSample(instanceKey{target: obs.Target, hostname: obs.Hostname}, obs.Version, obs)
+
+	result := s.trackUptime(obs)
+	if result.Restarted {
+		entry.Restarts++
+	}
+
+	return result
+}
+
+// Entries returns all non-expired entries ordered by target, hostname and version.
+func (s *Store) Entries(now time.Time) []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire(now)
+
+	entries := make([]Entry, 0, len(s.entries))
+
+	for _, entry := range s.entries {
+		snapshot := *entry
+		snapshot.State = StateActive
+
+		if now.Sub(entry.LastSeen) > s.staleAfter {
+			snapshot.State = StateStale
+		}
+
+		entries = append(entries, snapshot)
+	}
+
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return cmp.Or(
+			cmp.Compare(a.Target, b.Target),
+			cmp.Compare(a.Hostname, b.Hostname),
+			cmp.Compare(a.Version, b.Version),
+		)
+	})
+
+	return entries
+}
+
+// recordFailure counts a failed sample against the instance last seen at its endpoint
+// and adds it to the history of the instance. Endpoints that never answered get a
+// placeholder entry named after the endpoint, which stays active for as long as the
+// endpoint keeps failing.
+func (s *Store) recordFailure(obs Observation) {
+	if obs.Endpoint == "" {
+		return
+	}
+
+	key, found := s.endpoints[endpointKey{target: obs.Target, endpoint: obs.Endpoint}]
+	if !found {
+		key = entryKey{target: obs.Target, hostname: obs.Endpoint, version: unknownVersion}
+	}
+
+	entry := s.entry(key, obs.At)
+	entry.Errors++
+
+	if !found {
+		entry.LastSeen = obs.At
+	}
+
+	s.appendSample(instanceKey{target: key.target, hostname: key.hostname}, key.version, obs)
+}
+
+// entry returns the entry for key, creating it when missing.
+func (s *Store) entry(key entryKey, at time.Time) *Entry {
+	entry, found := s.entries[key]
+	if !found {
+		entry = &Entry{
+			Target:    key.target,
+			Hostname:  key.hostname,
+			Version:   key.version,
+			FirstSeen: at,
+		}
+		s.entries[key] = entry
+	}
+
+	return entry
+}
+
+// expire removes entries that have not been seen within the expiry threshold.
+func (s *Store) expire(now time.Time) {
+	for key, entry := range s.entries {
+		if now.Sub(entry.LastSeen) > s.expireAfter {
+			delete(s.entries, key)
+		}
+	}
+
+	for key, target := range s.endpoints {
+		if _, found := s.entries[target]; !found {
+			delete(s.endpoints, key)
+		}
+	}
+
+	s.expireUptimes(now)
+	s.expireHistories(now)
+}
@@ -0,0 +1,175 @@
+package inventory
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	defaultRestartWindow      = 10 * time.Minute
+	defaultCrashLoopThreshold = 3
+	maxEvents                 = 100
+	// startTolerance absorbs the rounding of reported uptimes when comparing process start times.
+	startTolerance = 2 * time.Second
+)
+
+// EventKind classifies an inventory event.
+type EventKind string
+
+const (
+	// EventRestart is emitted when the uptime of an instance went backwards.
+	EventRestart EventKind = "restart"
+	// EventCrashLoop is emitted when an instance restarted too often within the restart window.
+	EventCrashLoop EventKind = "crash_loop"
+)
+
+// Event is an entry of the inventory event feed.
+type Event struct {
+	Kind           EventKind     `json:"kind"`
+	Target         string        `json:"target"`
+	Hostname       string        `json:"hostname"`
+	Version        string        `json:"version"`
+	At             time.Time     `json:"at"`
+	PreviousUptime time.Duration `json:"previous_uptime"`
+	Uptime         time.Duration `json:"uptime"`
+	// Restarts is the number of restarts within the restart window, including this one.
+	Restarts int `json:"restarts"`
+}
+
+// RecordResult reports what the inventory learned about an instance from an observation.
+type RecordResult struct {
+	// Restarted is set when the observation revealed a restart.
+	Restarted bool
+	// Restarts is the number of restarts within the restart window.
+	Restarts int
+	// CrashLooping is set when Restarts reached the crash loop threshold.
+	CrashLooping bool
+}
+
+// WithRestartWindow sets the window in which restarts are counted.
+func WithRestartWindow(d time.Duration) Option {
+	return func(s *Store) {
+		if d > 0 {
+			s.restartWindow = d
+		}
+	}
+}
+
+// WithCrashLoopThreshold sets how many restarts within the window flag an instance as crash looping.
+func WithCrashLoopThreshold(n int) Option {
+	return func(s *Store) {
+		if n > 0 {
+			s.crashLoopThreshold = n
+		}
+	}
+}
+
+// uptimeState tracks the process start time of a single hostname across samples.
+type uptimeState struct {
+	started  time.Time
+	uptime   time.Duration
+	lastSeen time.Time
+	restarts []time.Time
+}
+
+type instanceKey struct {
+	target   string
+	hostname string
+}
+
+// Events returns the event feed, newest first.
+func (s *Store) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := slices.Clone(s.events)
+	slices.Reverse(events)
+
+	return events
+}
+
+// trackUptime compares the process start time implied by an observation, its timestamp
+// minus its uptime, with the latest one of the same hostname. A later start means the
+// container restarted in between. Comparing start times instead of uptimes keeps samples
+// that are recorded out of order, e.g. by overlapping requests, from looking like restarts.
+func (s *Store) trackUptime(obs Observation) RecordResult {
+	uptime, err := time.ParseDuration(obs.Uptime)
+	if err != nil {
+		return RecordResult{}
+	}
+
+	timestamp := obs.Timestamp
+	if timestamp.IsZero() {
+		timestamp = obs.At
+	}
+
+	started := timestamp.Add(-uptime)
+	key := instanceKey{target: obs.Target, hostname: obs.Hostname}
+
+	state, found := s.uptimes[key]
+	if !found {
+		state = &uptimeState{started: started, uptime: uptime}
+		s.uptimes[key] = state
+	}
+
+	previous := state.uptime
+	restarted := found && started.Sub(state.started) > startTolerance
+
+	if !started.Before(state.started) {
+		state.started = started
+		state.uptime = uptime
+	}
+
+	state.lastSeen = obs.At
+	state.restarts = slices.DeleteFunc(state.restarts, func(at time.Time) bool {
+		return obs.At.Sub(at) > s.restartWindow
+	})
+
+	if restarted {
+		state.restarts = append(state.restarts, obs.At)
+	}
+
+	result := RecordResult{
+		Restarted:    restarted,
+		Restarts:     len(state.restarts),
+		CrashLooping: len(state.restarts) >= s.crashLoopThreshold,
+	}
+
+	if restarted {
+		kind := EventRestart
+		if result.CrashLooping {
+			kind = EventCrashLoop
+		}
+
+		s.appendEvent(Event{
+			Kind:           kind,
+			Target:         obs.Target,
+			Hostname:       obs.Hostname,
+			Version:        obs.Version,
+			At:             obs.At,
+			PreviousUptime: previous,
+			Uptime:         uptime,
+			Restarts:       result.Restarts,
+		})
+	}
+
+	return result
+}
+
+// appendEvent adds an event to the feed, dropping the oldest event when full.
+func (s *Store) appendEvent(event Event) {
+	if len(s.events) == maxEvents {
+		s.events = slices.Delete(s.events, 0, 1)
+	}
+
+	s.events = append(s.events, event)
+}
+
+// expireUptimes forgets the uptime history of hostnames not seen within the expiry threshold.
+func (s *Store) expireUptimes(now time.Time) {
+	for key, state := range s.uptimes {
+		if now.Sub(state.lastSeen) > s.expireAfter {
+			delete(s.uptimes, key)
+		}
+	}
+}
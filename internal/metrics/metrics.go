@@ -0,0 +1,194 @@
+// Package metrics exposes counters and gauges in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	value atomic.Uint64
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add increments the counter by n.
+func (c *Counter) Add(n uint64) {
+	c.value.Add(n)
+}
+
+// Value returns the current value of the counter.
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	value atomic.Int64
+}
+
+// Inc increments the gauge by one.
+func (g *Gauge) Inc() {
+	g.value.Add(1)
+}
+
+// Dec decrements the gauge by one.
+func (g *Gauge) Dec() {
+	g.value.Add(-1)
+}
+
+// Value returns the current value of the gauge.
+func (g *Gauge) Value() int64 {
+	return g.value.Load()
+}
+
+// CounterVec is a family of counters partitioned by the value of a single label.
+type CounterVec struct {
+	mu       sync.Mutex
+	counters map[string]*Counter
+}
+
+// With returns the counter for the given label value, creating it when missing.
+func (v *CounterVec) With(value string) *Counter {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	counter, found := v.counters[value]
+	if !found {
+		counter = &Counter{}
+		v.counters[value] = counter
+	}
+
+	return counter
+}
+
+type metric struct {
+	name   string
+	help   string
+	kind   string
+	label  string
+	values func() []sample
+}
+
+type sample struct {
+	label string
+	value float64
+}
+
+// Registry holds metrics and serves them over HTTP, safe for concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewCounter registers a counter.
+func (r *Registry) NewCounter(name, help string) *Counter {
+	counter := &Counter{}
+
+	r.register(metric{name: name, help: help, kind: "counter", values: func() []sample {
+		return []sample{{value: float64(counter.Value())}}
+	}})
+
+	return counter
+}
+
+// NewCounterVec registers a counter family partitioned by label.
+func (r *Registry) NewCounterVec(name, help, label string) *CounterVec {
+	vec := &CounterVec{counters: make(map[string]*Counter)}
+
+	r.register(metric{name: name, help: help, kind: "counter", label: label, values: func() []sample {
+		vec.mu.Lock()
+		defer vec.mu.Unlock()
+
+		samples := make([]sample, 0, len(vec.counters))
+		for value, counter := range vec.counters {
+			samples = append(samples, sample{label: value, value: float64(counter.Value())})
+		}
+
+		return samples
+	}})
+
+	return vec
+}
+
+// NewGauge registers a gauge.
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	gauge := &Gauge{}
+
+	r.register(metric{name: name, help: help, kind: "gauge", values: func() []sample {
+		return []sample{{value: float64(gauge.Value())}}
+	}})
+
+	return gauge
+}
+
+// NewGaugeFunc registers a gauge whose value is computed by fn on every scrape.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(metric{name: name, help: help, kind: "gauge", values: func() []sample {
+		return []sample{{value: fn()}}
+	}})
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteTo writes all metrics in the Prometheus text exposition format.
+func (r *Registry) WriteTo(writer io.Writer) (int64, error) {
+	r.mu.Lock()
+	metrics := slices.Clone(r.metrics)
+	r.mu.Unlock()
+
+	slices.SortFunc(metrics, func(a, b metric) int { return strings.Compare(a.name, b.name) })
+
+	var builder strings.Builder
+
+	for _, m := range metrics {
+		fmt.Fprintf(&builder, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+
+		samples := m.values()
+		slices.SortFunc(samples, func(a, b sample) int { return strings.Compare(a.label, b.label) })
+
+		for _, s := range samples {
+			value := strconv.FormatFloat(s.value, 'g', -1, 64)
+
+			if m.label == "" {
+				fmt.Fprintf(&builder, "%s %s\n", m.name, value)
+			} else {
+				fmt.Fprintf(&builder, "%s{%s=%q} %s\n", m.name, m.label, s.label, value)
+			}
+		}
+	}
+
+	written, err := io.WriteString(writer, builder.String())
+	if err != nil {
+		return int64(written), fmt.Errorf("failed to write metrics: %w", err)
+	}
+
+	return int64(written), nil
+}
+
+// ServeHTTP serves the metrics for scraping.
+func (r *Registry) ServeHTTP(writer http.ResponseWriter, _ *http.Request) {
+	writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	_, _ = r.WriteTo(writer)
+}
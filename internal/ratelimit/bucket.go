@@ -0,0 +1,90 @@
+// Package ratelimit provides token buckets to limit how often work may be done.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket, safe for concurrent use. It holds up to burst tokens and
+// refills at rate tokens per second.
+type Bucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket creates a full bucket.
+func NewBucket(rate float64, burst int) *Bucket {
+	return &Bucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// Take removes a token from the bucket. When the bucket is empty, it reports how long
+// it takes until the next token becomes available.
+func (b *Bucket) Take(now time.Time) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+
+		return true, 0
+	}
+
+	return false, b.waitLocked()
+}
+
+// Available returns the number of tokens in the bucket.
+func (b *Bucket) Available(now time.Time) float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+
+	return b.tokens
+}
+
+// Wait returns how long it takes until a token is available.
+func (b *Bucket) Wait(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+
+	if b.tokens >= 1 {
+		return 0
+	}
+
+	return b.waitLocked()
+}
+
+// full reports whether the bucket has refilled completely.
+func (b *Bucket) full(now time.Time) bool {
+	return b.Available(now) >= b.burst
+}
+
+func (b *Bucket) refill(now time.Time) {
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+
+	b.last = now
+}
+
+func (b *Bucket) waitLocked() time.Duration {
+	if b.rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
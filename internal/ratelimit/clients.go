@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+// Clients keeps a token bucket per client key, safe for concurrent use. Buckets that
+// have refilled completely are dropped periodically, as they carry no state.
+type Clients struct {
+	rate  float64
+	burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*Bucket
+	lastSweep time.Time
+}
+
+// NewClients creates per-client buckets of the given rate and burst.
+func NewClients(rate float64, burst int) *Clients {
+	return &Clients{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*Bucket),
+	}
+}
+
+// Take removes a token from the bucket of the client.
+func (c *Clients) Take(key string, now time.Time) (bool, time.Duration) {
+	c.mu.Lock()
+
+	if now.Sub(c.lastSweep) > sweepInterval {
+		c.sweep(now)
+	}
+
+	bucket, found := c.buckets[key]
+	if !found {
+		bucket = NewBucket(c.rate, c.burst)
+		c.buckets[key] = bucket
+	}
+
+	c.mu.Unlock()
+
+	return bucket.Take(now)
+}
+
+func (c *Clients) sweep(now time.Time) {
+	for key, bucket := range c.buckets {
+		if bucket.full(now) {
+			delete(c.buckets, key)
+		}
+	}
+
+	c.lastSweep = now
+}
@@ -0,0 +1,127 @@
+package resilience
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState string
+
+const (
+	// BreakerClosed lets every call through.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen short-circuits every call until the open period ends.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets a single trial call through to decide whether to close again.
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// breaker is a consecutive-failure circuit breaker, safe for concurrent use.
+type breaker struct {
+	threshold int
+	openFor   time.Duration
+
+	mu          sync.Mutex
+	state       BreakerState
+	failures    int
+	openedAt    time.Time
+	trialActive bool
+	// generation changes with every state change, so that outcomes of calls allowed
+	// before it are ignored.
+	generation uint64
+}
+
+func newBreaker(threshold int, openFor time.Duration) *breaker {
+	return &breaker{
+		threshold: threshold,
+		openFor:   openFor,
+		state:     BreakerClosed,
+	}
+}
+
+// allow reports whether a call may proceed, and the generation the call must report its
+// outcome with. Once the open period has passed, a single trial call is let through
+// while the breaker is half-open.
+func (b *breaker) allow(now time.Time) (uint64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerClosed:
+		return b.generation, true
+	case BreakerOpen:
+		if now.Sub(b.openedAt) < b.openFor {
+			return 0, false
+		}
+
+		b.transition(BreakerHalfOpen)
+		b.trialActive = true
+
+		return b.generation, true
+	default:
+		if b.trialActive {
+			return 0, false
+		}
+
+		b.trialActive = true
+
+		return b.generation, true
+	}
+}
+
+// record reports the outcome of a call allowed in generation. Outcomes of calls allowed
+// before the last state change are ignored, so that a slow call admitted while closed
+// cannot decide a half-open trial.
+func (b *breaker) record(generation uint64, success bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+
+	if success {
+		b.failures = 0
+
+		if b.state != BreakerClosed {
+			b.transition(BreakerClosed)
+		}
+
+		return
+	}
+
+	b.failures++
+
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.transition(BreakerOpen)
+		b.openedAt = now
+	}
+}
+
+// abandon releases the trial slot of a call allowed in generation that ended without
+// telling whether the backend is healthy, e.g. because its caller went away. The next
+// call becomes the trial.
+func (b *breaker) abandon(generation uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation == b.generation {
+		b.trialActive = false
+	}
+}
+
+// transition moves the breaker to state and starts a new generation.
+func (b *breaker) transition(state BreakerState) {
+	b.state = state
+	b.trialActive = false
+	b.generation++
+}
+
+// current returns the state of the breaker.
+func (b *breaker) current() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
@@ -0,0 +1,251 @@
+// Package resilience wraps backend calls with retries, hedged requests and a circuit breaker.
+package resilience
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	defaultBackoffBase = 50 * time.Millisecond
+	defaultBackoffMax  = time.Second
+	defaultOpenFor     = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned when a call is short-circuited by an open circuit breaker.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Outcome reports how a call was executed.
+type Outcome struct {
+	// Retries is the number of attempts made after the first one.
+	Retries int
+	// Hedges is the number of hedged requests started.
+	Hedges int
+	// ShortCircuited is set when the circuit breaker rejected the call.
+	ShortCircuited bool
+}
+
+// Option configures a Policy.
+type Option func(*Policy)
+
+// WithRetries retries retryable failures up to maxRetries times. The wait before retry n
+// is drawn uniformly from zero to min(maxBackoff, base * 2^n).
+func WithRetries(maxRetries int, base, maxBackoff time.Duration) Option {
+	return func(p *Policy) {
+		if maxRetries > 0 {
+			p.maxRetries = maxRetries
+		}
+
+		if base > 0 {
+			p.backoffBase = base
+		}
+
+		if maxBackoff > 0 {
+			p.backoffMax = maxBackoff
+		}
+	}
+}
+
+// WithHedging starts a second, concurrent request when an attempt has not completed
+// after the given delay. The first successful response wins.
+func WithHedging(after time.Duration) Option {
+	return func(p *Policy) {
+		if after > 0 {
+			p.hedgeAfter = after
+		}
+	}
+}
+
+// WithCircuitBreaker opens the circuit after threshold consecutive failed calls and
+// short-circuits calls for openFor before letting a trial call through.
+func WithCircuitBreaker(threshold int, openFor time.Duration) Option {
+	return func(p *Policy) {
+		if threshold <= 0 {
+			return
+		}
+
+		if openFor <= 0 {
+			openFor = defaultOpenFor
+		}
+
+		p.breaker = newBreaker(threshold, openFor)
+	}
+}
+
+// WithRetryable sets the function that decides whether a failed attempt is retried.
+// By default every error is retried.
+func WithRetryable(retryable func(error) bool) Option {
+	return func(p *Policy) {
+		if retryable != nil {
+			p.retryable = retryable
+		}
+	}
+}
+
+// Policy is the resilience policy of one backend. The circuit breaker state is shared
+// by all calls made through the same policy.
+type Policy struct {
+	maxRetries  int
+	backoffBase time.Duration
+	backoffMax  time.Duration
+	hedgeAfter  time.Duration
+	breaker     *breaker
+	retryable   func(error) bool
+}
+
+// NewPolicy creates a policy. Without options, calls are executed once as-is.
+func NewPolicy(opts ...Option) *Policy {
+	policy := &Policy{
+		backoffBase: defaultBackoffBase,
+		backoffMax:  defaultBackoffMax,
+		retryable:   func(error) bool { return true },
+	}
+
+	for _, opt := range opts {
+		opt(policy)
+	}
+
+	return policy
+}
+
+// BreakerState returns the state of the circuit breaker, closed when none is configured.
+func (p *Policy) BreakerState() BreakerState {
+	if p == nil || p.breaker == nil {
+		return BreakerClosed
+	}
+
+	return p.breaker.current()
+}
+
+// Do executes fn according to the policy. A nil policy executes fn once.
+func Do[T any](ctx context.Context, policy *Policy, fn func(context.Context) (T, error)) (T, Outcome, error) {
+	var outcome Outcome
+
+	if policy == nil {
+		result, err := fn(ctx)
+
+		return result, outcome, err
+	}
+
+	var generation uint64
+
+	if policy.breaker != nil {
+		var allowed bool
+
+		generation, allowed = policy.breaker.allow(time.Now())
+		if !allowed {
+			var zero T
+
+			outcome.ShortCircuited = true
+
+			return zero, outcome, ErrCircuitOpen
+		}
+	}
+
+	result, err := retry(ctx, policy, fn, &outcome)
+
+	// A call canceled by its caller says nothing about the health of the backend, but it
+	// must still give up a half-open trial slot it may hold.
+	if policy.breaker != nil {
+		if err != nil && ctx.Err() != nil {
+			policy.breaker.abandon(generation)
+		} else {
+			policy.breaker.record(generation, err == nil, time.Now())
+		}
+	}
+
+	return result, outcome, err
+}
+
+// retry runs attempts until one succeeds, the error is not retryable, retries are
+// exhausted or ctx is done.
+func retry[T any](
+	ctx context.Context,
+	policy *Policy,
+	fn func(context.Context) (T, error),
+	outcome *Outcome,
+) (T, error) {
+	for attempt := 0; ; attempt++ {
+		result, hedged, err := hedge(ctx, policy, fn)
+		if hedged {
+			outcome.Hedges++
+		}
+
+		if err == nil || attempt >= policy.maxRetries || !policy.retryable(err) || ctx.Err() != nil {
+			return result, err
+		}
+
+		timer := time.NewTimer(policy.backoff(attempt))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return result, err
+		case <-timer.C:
+		}
+
+		outcome.Retries++
+	}
+}
+
+// backoff returns the jittered wait before the retry following attempt.
+func (p *Policy) backoff(attempt int) time.Duration {
+	ceiling := p.backoffMax
+	if shifted := p.backoffBase << attempt; shifted > 0 && shifted < ceiling {
+		ceiling = shifted
+	}
+
+	return rand.N(ceiling + 1) //nolint:gosec // Jitter does not need a secure random source.
+}
+
+type attemptResult[T any] struct {
+	value T
+	err   error
+}
+
+// hedge runs a single attempt, starting a second concurrent request when the first one
+// is slower than the hedging delay. It reports whether a hedged request was started.
+func hedge[T any](ctx context.Context, policy *Policy, fn func(context.Context) (T, error)) (T, bool, error) {
+	if policy.hedgeAfter <= 0 {
+		result, err := fn(ctx)
+
+		return result, false, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan attemptResult[T], 2) //nolint:mnd // The primary and the hedged request.
+	run := func() {
+		value, err := fn(ctx)
+		results <- attemptResult[T]{value: value, err: err}
+	}
+
+	go run()
+
+	timer := time.NewTimer(policy.hedgeAfter)
+	defer timer.Stop()
+
+	hedged := false
+	pending := 1
+
+	for {
+		select {
+		case <-timer.C:
+			hedged = true
+			pending++
+
+			go run()
+		case result := <-results:
+			pending--
+
+			// A failure only ends the attempt once no other request is in flight.
+			if result.err == nil || pending == 0 {
+				return result.value, hedged, result.err
+			}
+		}
+	}
+}
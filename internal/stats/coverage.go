@@ -0,0 +1,63 @@
+// Package stats provides the estimators and tests used to interpret sampled traffic.
+package stats
+
+import (
+	"math"
+)
+
+const (
+	singletonCount = 1
+	doubletonCount = 2
+)
+
+// Coverage estimates how many distinct instances exist behind a load balancer
+// from the number of times each observed instance was sampled.
+type Coverage struct {
+	// Samples is the number of successful samples.
+	Samples int
+	// Observed is the number of distinct instances seen.
+	Observed int
+	// Estimated is the estimated total number of instances, never below Observed.
+	Estimated int
+	// Confidence is the probability that an additional instance receiving an equal
+	// share of traffic would have shown up in the samples. It approaches 1 as more
+	// samples are taken without discovering new instances.
+	Confidence float64
+}
+
+// Complete reports whether the estimate suggests that every instance was observed.
+func (c Coverage) Complete() bool {
+	return c.Estimated == c.Observed
+}
+
+// EstimateCoverage estimates the total instance count with the bias-corrected Chao1
+// capture-recapture estimator, using the number of instances seen exactly once
+// (singletons) and exactly twice (doubletons).
+func EstimateCoverage(counts []int) Coverage {
+	var samples, singletons, doubletons int
+
+	for _, count := range counts {
+		samples += count
+
+		switch count {
+		case singletonCount:
+			singletons++
+		case doubletonCount:
+			doubletons++
+		}
+	}
+
+	observed := len(counts)
+	if observed == 0 {
+		return Coverage{}
+	}
+
+	unseen := float64(singletons*(singletons-1)) / float64(doubletonCount*(doubletons+1))
+
+	return Coverage{
+		Samples:    samples,
+		Observed:   observed,
+		Estimated:  observed + int(math.Ceil(unseen)),
+		Confidence: 1 - math.Pow(1-1/float64(observed+1), float64(samples)),
+	}
+}
@@ -0,0 +1,192 @@
+package stats
+
+import (
+	"math"
+)
+
+const (
+	// Z95 is the two-sided standard normal quantile for 95% confidence.
+	Z95 = 1.959963984540054
+	// SignificanceLevel is the p-value below which a deviation is considered real.
+	SignificanceLevel = 0.05
+	// minExpectedCount is the smallest expected cell count for which the chi-square
+	// approximation is considered reliable.
+	minExpectedCount = 5
+
+	gammaMaxIterations = 200
+	gammaEpsilon       = 1e-12
+	gammaTiny          = 1e-300
+	half               = 0.5
+)
+
+// Interval is a confidence interval of a proportion.
+type Interval struct {
+	Low  float64
+	High float64
+}
+
+// Contains reports whether p lies within the interval.
+func (i Interval) Contains(p float64) bool {
+	return p >= i.Low && p <= i.High
+}
+
+// WilsonInterval returns the Wilson score interval of successes out of n trials
+// for the given normal quantile z.
+func WilsonInterval(successes, n int, z float64) Interval {
+	if n == 0 {
+		return Interval{Low: 0, High: 1}
+	}
+
+	trials := float64(n)
+	p := float64(successes) / trials
+	z2 := z * z
+
+	denominator := 1 + z2/trials
+	center := (p + z2/(2*trials)) / denominator
+	margin := z / denominator * math.Sqrt(p*(1-p)/trials+z2/(4*trials*trials))
+
+	return Interval{
+		Low:  math.Max(0, center-margin),
+		High: math.Min(1, center+margin),
+	}
+}
+
+// GoodnessOfFit is the result of a chi-square goodness-of-fit test.
+type GoodnessOfFit struct {
+	ChiSquare        float64
+	DegreesOfFreedom int
+	PValue           float64
+	// Approximate is set when some expected counts are too small for the
+	// chi-square approximation to be reliable.
+	Approximate bool
+}
+
+// Significant reports whether the observed counts deviate from the expected shares
+// beyond what sampling noise explains.
+func (g GoodnessOfFit) Significant() bool {
+	return g.PValue < SignificanceLevel
+}
+
+// ChiSquareTest tests observed counts against expected shares. Shares are normalized
+// and must have the same length as observed. An observation in a category with an
+// expected share of zero is an impossible outcome and yields a p-value of zero.
+func ChiSquareTest(observed []int, expectedShares []float64) GoodnessOfFit {
+	var total int
+
+	var shareSum float64
+
+	for i := range observed {
+		total += observed[i]
+		shareSum += expectedShares[i]
+	}
+
+	if total == 0 || shareSum == 0 {
+		return GoodnessOfFit{PValue: 1}
+	}
+
+	var (
+		result     GoodnessOfFit
+		categories int
+	)
+
+	for i, count := range observed {
+		expected := float64(total) * expectedShares[i] / shareSum
+		if expected == 0 {
+			if count > 0 {
+				result.ChiSquare = math.Inf(1)
+			}
+
+			continue
+		}
+
+		if expected < minExpectedCount {
+			result.Approximate = true
+		}
+
+		diff := float64(count) - expected
+		result.ChiSquare += diff * diff / expected
+		categories++
+	}
+
+	result.DegreesOfFreedom = max(categories-1, 1)
+	result.PValue = ChiSquareSurvival(result.ChiSquare, result.DegreesOfFreedom)
+
+	return result
+}
+
+// ChiSquareSurvival returns P(X >= x) for a chi-square distribution with df degrees of freedom.
+func ChiSquareSurvival(x float64, df int) float64 {
+	if math.IsInf(x, 1) {
+		return 0
+	}
+
+	if x <= 0 {
+		return 1
+	}
+
+	return regularizedGammaQ(float64(df)*half, x*half)
+}
+
+// regularizedGammaQ computes the regularized upper incomplete gamma function Q(a, x),
+// using the series expansion for small x and a continued fraction otherwise.
+func regularizedGammaQ(a, x float64) float64 {
+	if x < a+1 {
+		return 1 - gammaSeries(a, x)
+	}
+
+	return gammaContinuedFraction(a, x)
+}
+
+// gammaSeries computes the regularized lower incomplete gamma function P(a, x) by its series.
+func gammaSeries(a, x float64) float64 {
+	lgamma, _ := math.Lgamma(a)
+
+	term := 1 / a
+	sum := term
+
+	for n := 1; n < gammaMaxIterations; n++ {
+		term *= x / (a + float64(n))
+		sum += term
+
+		if math.Abs(term) < math.Abs(sum)*gammaEpsilon {
+			break
+		}
+	}
+
+	return sum * math.Exp(-x+a*math.Log(x)-lgamma)
+}
+
+// gammaContinuedFraction computes Q(a, x) by Lentz's continued fraction method.
+func gammaContinuedFraction(a, x float64) float64 {
+	lgamma, _ := math.Lgamma(a)
+
+	b := x + 1 - a
+	c := 1 / gammaTiny
+	d := 1 / b
+	h := d
+
+	for i := 1; i < gammaMaxIterations; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+
+		d = an*d + b
+		if math.Abs(d) < gammaTiny {
+			d = gammaTiny
+		}
+
+		c = b + an/c
+		if math.Abs(c) < gammaTiny {
+			c = gammaTiny
+		}
+
+		d = 1 / d
+		delta := d * c
+		h *= delta
+
+		if math.Abs(delta-1) < gammaEpsilon {
+			break
+		}
+	}
+
+	return math.Exp(-x+a*math.Log(x)-lgamma) * h
+}
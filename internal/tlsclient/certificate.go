@@ -0,0 +1,66 @@
+package tlsclient
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileVersion identifies the content of a file by its modification time and size.
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+// reloadingCertificate is a client certificate that is reloaded from disk when its files
+// change, safe for concurrent use. Connections pick up a new certificate on their next handshake.
+type reloadingCertificate struct {
+	certFile string
+	keyFile  string
+
+	mu          sync.Mutex
+	certificate *tls.Certificate
+	certVersion fileVersion
+	keyVersion  fileVersion
+}
+
+// get returns the current certificate, reloading it when its files changed. While the
+// files are being rotated and do not form a valid pair, the previous certificate is kept.
+func (c *reloadingCertificate) get() (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	certVersion, certErr := statFile(c.certFile)
+	keyVersion, keyErr := statFile(c.keyFile)
+
+	unchanged := certVersion == c.certVersion && keyVersion == c.keyVersion
+	if c.certificate != nil && (unchanged || certErr != nil || keyErr != nil) {
+		return c.certificate, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		if c.certificate != nil {
+			return c.certificate, nil
+		}
+
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	c.certificate = &certificate
+	c.certVersion = certVersion
+	c.keyVersion = keyVersion
+
+	return c.certificate, nil
+}
+
+func statFile(path string) (fileVersion, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileVersion{}, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	return fileVersion{modTime: info.ModTime(), size: info.Size()}, nil
+}
@@ -0,0 +1,125 @@
+// Package tlsclient builds TLS configurations for connections to backends, with private
+// CA bundles and client certificates that are reloaded when they change on disk.
+package tlsclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// ErrNoCertificates is returned when a CA bundle holds no PEM encoded certificates.
+	ErrNoCertificates = errors.New("no certificates found in CA bundle")
+	// ErrUnknownVersion is returned for TLS versions other than 1.2 and 1.3.
+	ErrUnknownVersion = errors.New("unknown TLS version")
+)
+
+// Option configures a TLS configuration built by New.
+type Option func(*options)
+
+type options struct {
+	caFile     string
+	certFile   string
+	keyFile    string
+	serverName string
+	minVersion uint16
+}
+
+// WithCABundle verifies servers against the PEM encoded certificates in path instead of
+// the system roots.
+func WithCABundle(path string) Option {
+	return func(o *options) { o.caFile = path }
+}
+
+// WithClientCertificate presents the PEM encoded certificate and key in certFile and
+// keyFile to servers that ask for one. Both files are reloaded when they change.
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(o *options) {
+		o.certFile = certFile
+		o.keyFile = keyFile
+	}
+}
+
+// WithServerName verifies the server certificate against name instead of the host of the URL.
+func WithServerName(name string) Option {
+	return func(o *options) { o.serverName = name }
+}
+
+// WithMinVersion sets the minimum TLS version, e.g. tls.VersionTLS13. The default is TLS 1.2.
+func WithMinVersion(version uint16) Option {
+	return func(o *options) {
+		if version != 0 {
+			o.minVersion = version
+		}
+	}
+}
+
+// New builds a client TLS configuration. The CA bundle and the client certificate are
+// loaded once to fail early on broken files.
+func New(opts ...Option) (*tls.Config, error) {
+	o := options{minVersion: tls.VersionTLS12}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	config := &tls.Config{
+		MinVersion: o.minVersion,
+		ServerName: o.serverName,
+	}
+
+	if o.caFile != "" {
+		pool, err := loadCABundle(o.caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		config.RootCAs = pool
+	}
+
+	if o.certFile != "" || o.keyFile != "" {
+		certificate := &reloadingCertificate{certFile: o.certFile, keyFile: o.keyFile}
+
+		_, err := certificate.get()
+		if err != nil {
+			return nil, err
+		}
+
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return certificate.get()
+		}
+	}
+
+	return config, nil
+}
+
+// ParseVersion parses a TLS version like "1.2" or "1.3". An empty version returns zero.
+func ParseVersion(version string) (uint16, error) {
+	switch version {
+	case "":
+		return 0, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("%w: %s", ErrUnknownVersion, version)
+	}
+}
+
+func loadCABundle(path string) (*x509.CertPool, error) {
+	bundle, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bundle) {
+		return nil, fmt.Errorf("%w: %s", ErrNoCertificates, path)
+	}
+
+	return pool, nil
+}
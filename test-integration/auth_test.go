@@ -0,0 +1,204 @@
+package integration_test
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"phasor-frontend/internal/auth"
+	"phasor-frontend/internal/config"
+	"testing"
+	"time"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestBackendAuth(t *testing.T) {
+	t.Parallel()
+
+	t.Run("bearer token authenticates samples and health checks", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a backend that requires a bearer token
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.RequireAuth(func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer s3cret" })
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name: config.DefaultTargetName,
+				Auth: config.AuthConfig{
+					Type:  config.AuthTypeBearer,
+					Token: "s3cret",
+				},
+			}}
+		})
+		defer frontend.Close()
+
+		// WHEN: tiles and the readiness are requested
+		tiles := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer tiles.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		ready := httpGet(t, frontend.URL+"/health/ready")
+		defer ready.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: both requests are authenticated
+		testastic.NotContains(t, readBody(t, tiles), "<div>Uptime: N/A</div>")
+		testastic.Equal(t, http.StatusOK, ready.StatusCode)
+	})
+
+	t.Run("requests without credentials are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a backend that requires a bearer token and a frontend without credentials
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.RequireAuth(func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer s3cret" })
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: tiles and the readiness are requested
+		tiles := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer tiles.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		ready := httpGet(t, frontend.URL+"/health/ready")
+		defer ready.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the sample fails and the backend is reported as failing
+		testastic.Contains(t, readBody(t, tiles), "<div>Uptime: N/A</div>")
+		testastic.Equal(t, http.StatusServiceUnavailable, ready.StatusCode)
+	})
+
+	t.Run("rotated token file is re-read", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend that reads its token from a file, which is rotated afterwards
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.RequireAuth(func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer second" })
+
+		tokenFile := writeTestFile(t, t.TempDir(), "token", []byte("first\n"))
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name: config.DefaultTargetName,
+				Auth: config.AuthConfig{
+					Type:      config.AuthTypeBearerFile,
+					TokenFile: tokenFile,
+				},
+			}}
+		})
+		defer frontend.Close()
+
+		rejected := httpGet(t, frontend.URL+"/tiles?count=1")
+		testastic.Contains(t, readBody(t, rejected), "<div>Uptime: N/A</div>")
+		_ = rejected.Body.Close()
+
+		testastic.NoError(t, os.WriteFile(tokenFile, []byte("second\n"), 0o600))
+
+		later := time.Now().Add(time.Minute)
+		testastic.NoError(t, os.Chtimes(tokenFile, later, later))
+
+		// WHEN: tiles are requested after the rotation
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the new token is sent
+		testastic.NotContains(t, readBody(t, resp), "<div>Uptime: N/A</div>")
+	})
+
+	t.Run("basic auth authenticates samples", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a backend that requires basic auth
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.RequireAuth(func(r *http.Request) bool {
+			username, password, ok := r.BasicAuth()
+
+			return ok && username == "phasor" && password == "s3cret"
+		})
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name: config.DefaultTargetName,
+				Auth: config.AuthConfig{
+					Type:     config.AuthTypeBasic,
+					Username: "phasor",
+					Password: "s3cret",
+				},
+			}}
+		})
+		defer frontend.Close()
+
+		// WHEN: tiles are requested
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the sample is authenticated
+		testastic.NotContains(t, readBody(t, resp), "<div>Uptime: N/A</div>")
+	})
+
+	t.Run("hmac signature authenticates samples", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a backend that verifies HMAC signatures
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.RequireAuth(func(r *http.Request) bool {
+			timestamp := r.Header.Get(auth.TimestampHeader)
+
+			return timestamp != "" &&
+				r.Header.Get(auth.SignatureHeader) == auth.Sign("s3cret", r.Method, r.URL.RequestURI(), timestamp)
+		})
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name: config.DefaultTargetName,
+				Auth: config.AuthConfig{
+					Type:       config.AuthTypeHMAC,
+					HMACSecret: "s3cret",
+				},
+			}}
+		})
+		defer frontend.Close()
+
+		// WHEN: tiles are requested
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the sample is authenticated
+		testastic.NotContains(t, readBody(t, resp), "<div>Uptime: N/A</div>")
+	})
+
+	t.Run("secrets are redacted when logged", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: auth settings with secrets
+		authCfg := config.AuthConfig{
+			Type:       config.AuthTypeBasic,
+			Token:      "token-value",
+			Password:   "password-value",
+			HMACSecret: "hmac-value",
+		}
+
+		// WHEN: the settings are logged and formatted
+		var logs bytes.Buffer
+
+		slog.New(slog.NewJSONHandler(&logs, nil)).Info("auth", "config", authCfg, "password", authCfg.Password)
+		formatted := fmt.Sprintf("%v %+v %#v %s", authCfg, authCfg, authCfg, authCfg.Password)
+
+		// THEN: none of the secrets appear
+		for _, secret := range []string{"token-value", "password-value", "hmac-value"} {
+			testastic.NotContains(t, logs.String(), secret)
+			testastic.NotContains(t, formatted, secret)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package integration_test
+
+import (
+	"net/http"
+	"phasor-frontend/internal/config"
+	"testing"
+	"time"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestRequestBudget(t *testing.T) {
+	t.Parallel()
+
+	t.Run("timeout parameter returns the samples finished in time", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a backend that answers slowly, so only one of three samples fits in the timeout
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.DelayNext(3, 200*time.Millisecond)
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: tiles are requested with a timeout
+		resp := httpGet(t, frontend.URL+"/tiles?count=3&timeout=300ms")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the finished sample is shown and the unfinished ones are marked as not sampled
+		body := readBody(t, resp)
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+		testastic.Contains(t, body, `<div class="budget">time budget of 300ms exhausted: 1 of 3 samples finished</div>`)
+		testastic.Contains(t, body, "test-host")
+		testastic.Contains(t, body, `<div class="flag">not sampled</div>`)
+	})
+
+	t.Run("timeout parameter cannot exceed the configured budget", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend with a short budget in front of a slow backend
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.DelayNext(3, 200*time.Millisecond)
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Sampling.Budget = 300 * time.Millisecond
+		})
+		defer frontend.Close()
+
+		// WHEN: tiles are requested with a longer timeout
+		resp := httpGet(t, frontend.URL+"/tiles?count=3&timeout=1m")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the configured budget applies
+		testastic.Contains(t, readBody(t, resp), "time budget of 300ms exhausted: 1 of 3 samples finished")
+	})
+
+	t.Run("requests within the budget are complete", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend with a budget in front of a fast backend
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Sampling.Budget = time.Minute
+		})
+		defer frontend.Close()
+
+		// WHEN: tiles are requested
+		resp := httpGet(t, frontend.URL+"/tiles?count=3")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: every sample finished
+		body := readBody(t, resp)
+		testastic.NotContains(t, body, `class="budget"`)
+		testastic.NotContains(t, body, "not sampled")
+	})
+}
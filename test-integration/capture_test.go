@@ -0,0 +1,75 @@
+package integration_test
+
+import (
+	"net/http"
+	"phasor-frontend/internal/config"
+	"testing"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestResponseHeaderCapture(t *testing.T) {
+	t.Parallel()
+
+	t.Run("captured headers show on tiles and group the summary", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: two edge proxies in front of three pods, and a target that captures their headers
+		backend := newMockBackendWithHostnames("1.0.0", "pod-a", "pod-b", "pod-c")
+		defer backend.Close()
+
+		backend.SetResponseHeaders(func(hostname string) http.Header {
+			servedBy := "edge-1"
+			if hostname == "pod-c" {
+				servedBy = "edge-2"
+			}
+
+			return http.Header{"X-Served-By": {servedBy}, "Server": {"envoy"}, "X-Internal": {"hidden"}}
+		})
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name:           config.DefaultTargetName,
+				CaptureHeaders: []string{"x-served-by", "server"},
+			}}
+		})
+		defer frontend.Close()
+
+		// WHEN: tiles are requested grouped by the edge proxy
+		resp := httpGet(t, frontend.URL+"/tiles?count=6&group=X-Served-By")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: tiles show the captured headers and the summary correlates proxies and pods
+		body := readBody(t, resp)
+		testastic.Contains(t, body, `<div class="header">x-served-by: edge-2</div><div class="header">server: envoy</div>`)
+		testastic.NotContains(t, body, "hidden")
+		testastic.Contains(t, body,
+			`<div class="group">x-served-by=edge-1: 4 samples, hostnames pod-a,pod-b, versions 1.0.0</div>`)
+		testastic.Contains(t, body,
+			`<div class="group">x-served-by=edge-2: 2 samples, hostnames pod-c, versions 1.0.0</div>`)
+	})
+
+	t.Run("samples without the grouping header form their own group", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a backend that sends no routing headers
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name:           config.DefaultTargetName,
+				CaptureHeaders: []string{"x-served-by"},
+			}}
+		})
+		defer frontend.Close()
+
+		// WHEN: tiles are requested grouped by the missing header
+		resp := httpGet(t, frontend.URL+"/tiles?count=2&group=x-served-by")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: all samples are in the missing group
+		testastic.Contains(t, readBody(t, resp),
+			`<div class="group">x-served-by=(missing): 2 samples, hostnames test-host, versions 1.0.0</div>`)
+	})
+}
@@ -0,0 +1,82 @@
+package integration_test
+
+import (
+	"net/http"
+	"phasor-frontend/internal/config"
+	"testing"
+	"time"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestClockSkew(t *testing.T) {
+	t.Parallel()
+
+	t.Run("instance with a clock ahead beyond the threshold is flagged", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a backend whose clock is an hour ahead
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.SetClockSkew(time.Hour)
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: requesting a tile
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the tile shows the skew and the timestamp lies in the future
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body := readBody(t, resp)
+		testastic.Contains(t, body, `<div class="flag">clock skew: `)
+		testastic.Contains(t, body, "(in 1h0m0s)")
+	})
+
+	t.Run("skew within the threshold is not flagged", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a backend whose clock lags by a minute and a threshold of five minutes
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.SetClockSkew(-time.Minute)
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Clock.SkewThreshold = 5 * time.Minute
+		})
+		defer frontend.Close()
+
+		// WHEN: requesting a tile
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the tile is not flagged and the timestamp is shown relative to now
+		body := readBody(t, resp)
+		testastic.NotContains(t, body, "clock skew")
+		testastic.Contains(t, body, "(1m0s ago)")
+	})
+
+	t.Run("timestamps are rendered in the configured time zone", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend configured to show timestamps in Tokyo time
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Clock.Timezone = "Asia/Tokyo"
+		})
+		defer frontend.Close()
+
+		// WHEN: requesting a tile
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the timestamp carries the zone abbreviation
+		testastic.Contains(t, readBody(t, resp), " JST (")
+	})
+}
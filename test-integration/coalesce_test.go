@@ -0,0 +1,98 @@
+package integration_test
+
+import (
+	"net/http"
+	"phasor-frontend/internal/config"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestRequestCoalescing(t *testing.T) {
+	t.Parallel()
+
+	t.Run("concurrent identical requests share one sampling run", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a backend that answers its next request slowly
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.DelayNext(1, 300*time.Millisecond)
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: two clients request the same tiles at the same time
+		bodies := make([]string, 2)
+
+		var waitGroup sync.WaitGroup
+
+		for i := range bodies {
+			waitGroup.Go(func() {
+				resp := httpGet(t, frontend.URL+"/tiles?count=1")
+				defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+				bodies[i] = readBody(t, resp)
+			})
+		}
+
+		waitGroup.Wait()
+
+		// THEN: the backend is sampled once and one response says it was shared
+		testastic.Equal(t, uint64(1), backend.Requests())
+		testastic.Contains(t, bodies[0]+bodies[1], "shared with a concurrent request")
+	})
+
+	t.Run("identical requests within the cache ttl reuse the result", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend that caches results for a minute and served a request
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Coalescing.CacheTTL = time.Minute
+		})
+		defer frontend.Close()
+
+		first := httpGet(t, frontend.URL+"/tiles?count=2")
+		_ = first.Body.Close()
+
+		// WHEN: the same tiles are requested again
+		resp := httpGet(t, frontend.URL+"/tiles?count=2")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the cached result is served with its age
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+		testastic.Equal(t, "0", resp.Header.Get("Age"))
+		testastic.Contains(t, readBody(t, resp), `<div class="freshness">sampled just now, from cache</div>`)
+		testastic.Equal(t, uint64(2), backend.Requests())
+	})
+
+	t.Run("requests with different parameters are sampled separately", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend that caches results for a minute and served a request
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Coalescing.CacheTTL = time.Minute
+		})
+		defer frontend.Close()
+
+		first := httpGet(t, frontend.URL+"/tiles?count=2")
+		_ = first.Body.Close()
+
+		// WHEN: tiles are requested with a different count
+		resp := httpGet(t, frontend.URL+"/tiles?count=3")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the backend is sampled again
+		testastic.Equal(t, "", resp.Header.Get("Age"))
+		testastic.Equal(t, uint64(5), backend.Requests())
+	})
+}
@@ -0,0 +1,203 @@
+package integration_test
+
+import (
+	"net/http/httptest"
+	"phasor-frontend/internal/config"
+	"phasor-frontend/testutil"
+	"regexp"
+	"testing"
+
+	"github.com/monkescience/testastic"
+)
+
+// twoTileColors is a palette in which pod-a and pod-c hash to the same color.
+var twoTileColors = []string{"#111111", "#222222"}
+
+func TestTileColorAssignment(t *testing.T) {
+	t.Parallel()
+
+	t.Run("instances whose hashes collide get distinct colors", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: two instances that hash to the same tile color
+		backend := newMockBackendWithHostnames("1.0.0", "pod-a", "pod-c")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: tiles are requested twice
+		first := httpGet(t, frontend.URL+"/tiles?count=4")
+		firstBody := readBody(t, first)
+		_ = first.Body.Close()
+
+		resp := httpGet(t, frontend.URL+"/tiles?count=4")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the instances have distinct colors that stay the same across requests
+		body := readBody(t, resp)
+		testastic.NotEqual(t, hostnameColor(t, body, "pod-a"), hostnameColor(t, body, "pod-c"))
+		testastic.Equal(t, hostnameColor(t, firstBody, "pod-a"), hostnameColor(t, body, "pod-a"))
+		testastic.Equal(t, hostnameColor(t, firstBody, "pod-c"), hostnameColor(t, body, "pod-c"))
+	})
+
+	t.Run("exhausted palette is extended with generated colors", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: three instances and a palette of two colors
+		backend := newMockBackendWithHostnames("1.0.0", "pod-a", "pod-b", "pod-c")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: tiles are requested
+		resp := httpGet(t, frontend.URL+"/tiles?count=6")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: every instance has its own color, one of them generated
+		body := readBody(t, resp)
+		colors := map[string]bool{}
+
+		for _, hostname := range []string{"pod-a", "pod-b", "pod-c"} {
+			colors[hostnameColor(t, body, hostname)] = true
+		}
+
+		testastic.Equal(t, 3, len(colors))
+		testastic.True(t, colors["#111111"] && colors["#222222"])
+	})
+
+	t.Run("hash assignment keeps colliding colors", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: two instances that hash to the same tile color and hash assignment
+		backend := newMockBackendWithHostnames("1.0.0", "pod-a", "pod-c")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) { cfg.TileColorAssignment = "hash" })
+		defer frontend.Close()
+
+		// WHEN: tiles are requested
+		resp := httpGet(t, frontend.URL+"/tiles?count=4")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: both instances have their hashed color
+		body := readBody(t, resp)
+		testastic.Equal(t, hostnameColor(t, body, "pod-a"), hostnameColor(t, body, "pod-c"))
+	})
+
+	t.Run("request chooses the attribute behind each color and gets a legend", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: two instances running the same version
+		backend := newMockBackendWithHostnames("1.0.0", "pod-a", "pod-c")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: tiles are requested with the hostname color driven by the version
+		resp := httpGet(t, frontend.URL+"/tiles?count=4&hostname_color=version&version_color=hostname")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: both hostnames share a color and the legend explains both colors
+		body := readBody(t, resp)
+		testastic.Equal(t, hostnameColor(t, body, "pod-a"), hostnameColor(t, body, "pod-c"))
+		testastic.Contains(t, body, `<div class="legend">hostname color by version: <span style="color: `)
+		testastic.Contains(t, body, `<div class="legend">version color by hostname: <span style="color: `)
+	})
+
+	t.Run("pinned version colors are used and marked in the legend", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a backend without pod names and a pinned color for its version
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.VersionColors = map[string]string{"1.0.0": "#0000ff"}
+		})
+		defer frontend.Close()
+
+		// WHEN: tiles are requested
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the version color is the pinned one
+		body := readBody(t, resp)
+		testastic.Contains(t, body, `<span style="color: #0000ff; float: right;">1.0.0</span>`)
+		testastic.Contains(t, body,
+			`<div class="legend">version color by revision: <span style="color: #0000ff;">1.0.0</span> (pinned)</div>`)
+	})
+
+	t.Run("text colors reach a readable contrast in both themes", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a yellow tile color that is unreadable on the white tiles of the light theme
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) { cfg.TileColors = []string{"#ffff00"} })
+		defer frontend.Close()
+
+		// WHEN: tiles are requested
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the text is darkened for the light theme and kept for the dark theme
+		testastic.Contains(t, readBody(t, resp),
+			`<div class="text-colors">hostname #737300/#ffff00, version #737300/#ffff00</div>`)
+	})
+
+	t.Run("color-blind-safe palette and markers are chosen per request", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend configured with its own tile colors and without markers
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: tiles are requested with the Okabe-Ito palette, markers and version colors
+		resp := httpGet(t, frontend.URL+"/tiles?count=1&palette=okabe_ito&markers=true&version_color=version")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: tiles use the palette and carry a shape marker
+		body := readBody(t, resp)
+		testastic.True(t, regexp.MustCompile(
+			`border-right: 6px solid (#e69f00|#56b4e9|#009e73|#f0e442|#0072b2|#d55e00|#cc79a7|#999999);`).MatchString(body))
+		testastic.Contains(t, body, `<div class="marker">●</div>`)
+	})
+}
+
+// hostnameColor returns the hostname color of the first tile of hostname.
+func hostnameColor(t *testing.T, body, hostname string) string {
+	t.Helper()
+
+	match := regexp.MustCompile(`<h3><span style="color: (#[0-9a-f]{6});">` + regexp.QuoteMeta(hostname) + `</span>`).
+		FindStringSubmatch(body)
+	testastic.True(t, match != nil)
+
+	return match[1]
+}
+
+// newColorServer starts a frontend with a palette of two colors, adjusted by configure when set.
+func newColorServer(t *testing.T, backendURL string, configure func(*config.Config)) *httptest.Server {
+	t.Helper()
+
+	cfg := &config.Config{
+		BackendURL:  backendURL + "/instance/info",
+		Environment: "test",
+		TileColors:  twoTileColors,
+	}
+
+	if configure != nil {
+		configure(cfg)
+	}
+
+	frontend, err := testutil.NewTestServerWithConfig(t.Context(), cfg, templatesPath(), testutil.NewTestLogger(t))
+	testastic.NoError(t, err)
+
+	return frontend
+}
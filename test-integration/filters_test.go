@@ -0,0 +1,96 @@
+package integration_test
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestTileFilters(t *testing.T) {
+	t.Parallel()
+
+	t.Run("filters keep the matching tiles and the summary covers all samples", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a backend where every other sample is answered by version 1.10.0 instead of 1.9.0
+		backend := newMockBackendWithHostnames("1.9.0", "web-1", "web-2", "api-1", "api-2")
+		defer backend.Close()
+
+		var requests atomic.Uint64
+
+		backend.SetCanary("1.10.0", func(*http.Request) bool { return requests.Add(1)%2 == 0 })
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		tests := []struct {
+			filter   string
+			expected string
+		}{
+			{"version=1.10.0", "showing 2 of 4 tiles: version 1.10.0"},
+			{"exclude_version=1.10.0", "showing 2 of 4 tiles: not version 1.10.0"},
+			{"only=outdated", "showing 2 of 4 tiles: only outdated"},
+			{"hostname=web-*", "showing 2 of 4 tiles: hostname web-*"},
+			{"hostname=" + url.QueryEscape(`/^api-\d$/`), `showing 2 of 4 tiles: hostname /^api-\d$/`},
+		}
+
+		for _, test := range tests {
+			// WHEN: requesting four tiles with the filter
+			resp := httpGet(t, frontend.URL+"/tiles?count=4&"+test.filter)
+			body := readBody(t, resp)
+			_ = resp.Body.Close()
+
+			// THEN: two tiles are rendered, with the filter, and the summary counts all four samples
+			testastic.Contains(t, body, `<div class="filter">`+test.expected+"</div>")
+			testastic.Contains(t, body, "saw 4 of")
+			testastic.Equal(t, 2, strings.Count(body, `<div class="tile"`))
+		}
+	})
+
+	t.Run("errors filter shows only failed samples", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a backend whose first answer fails
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.FailNext(1)
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: requesting three tiles that show only errors
+		resp := httpGet(t, frontend.URL+"/tiles?count=3&only=errors&only=ignored")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: only the tile of the failed sample is rendered
+		body := readBody(t, resp)
+		testastic.Contains(t, body, `<div class="filter">showing 1 of 3 tiles: only errors</div>`)
+		testastic.Contains(t, body, "failed to fetch")
+		testastic.Equal(t, 1, strings.Count(body, `<div class="tile"`))
+	})
+
+	t.Run("invalid filters are ignored and filters are part of the permalink", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend server connected to a backend
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: changing the view to an invalid hostname regex and a version list with blanks
+		resp := htmxGet(t, frontend.URL+"/tiles?count=2&hostname=/(/&version=1.0.0,,%201.1.0", "update")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the hostname filter is dropped and the cleaned up version filter is pushed
+		testastic.Equal(t, "/?count=2&version=1.0.0%2C1.1.0", resp.Header.Get("HX-Push-Url"))
+		testastic.Contains(t, readBody(t, resp),
+			`<div class="filter">showing 2 of 2 tiles: version 1.0.0 or 1.1.0</div>`)
+	})
+}
@@ -0,0 +1,104 @@
+package integration_test
+
+import (
+	"context"
+	"net/http"
+	"phasor-frontend/internal/config"
+	"testing"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestHeaderForwarding(t *testing.T) {
+	t.Parallel()
+
+	t.Run("static and allowlisted headers and cookies are sent to the backend", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a target with a static header and allowlisted browser headers and cookies
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name:           config.DefaultTargetName,
+				Headers:        map[string]string{"X-Dashboard": "phasor"},
+				ForwardHeaders: []string{"x-tenant"},
+				ForwardCookies: []string{"session"},
+			}}
+		})
+		defer frontend.Close()
+
+		// WHEN: the browser requests tiles with headers and cookies
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, frontend.URL+"/tiles?count=1", nil)
+		testastic.NoError(t, err)
+
+		req.Header.Set("X-Tenant", "blue")
+		req.Header.Set("X-Private", "do-not-forward")
+		req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+		req.AddCookie(&http.Cookie{Name: "tracking", Value: "do-not-forward"})
+
+		resp, err := http.DefaultClient.Do(req)
+		testastic.NoError(t, err)
+
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: only the static header and the allowlisted ones reach the backend
+		header := backend.LastHeader()
+		testastic.Equal(t, "phasor", header.Get("X-Dashboard"))
+		testastic.Equal(t, "blue", header.Get("X-Tenant"))
+		testastic.Equal(t, "", header.Get("X-Private"))
+		testastic.Equal(t, "session=abc", header.Get("Cookie"))
+	})
+
+	t.Run("canary toggle routes samples to the canary", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a mesh that routes requests with x-canary: always to a canary
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.SetCanary("2.0.0-rc.1", func(r *http.Request) bool { return r.Header.Get("X-Canary") == "always" })
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name:   config.DefaultTargetName,
+				Canary: config.CanaryConfig{Header: "x-canary", Value: "always"},
+			}}
+		})
+		defer frontend.Close()
+
+		stable := httpGet(t, frontend.URL+"/tiles?count=1")
+		stableBody := readBody(t, stable)
+		_ = stable.Body.Close()
+
+		// WHEN: the user turns on "route me to canary"
+		resp := httpGet(t, frontend.URL+"/tiles?count=1&canary=true")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the tiles switch to the canary
+		body := readBody(t, resp)
+		testastic.Contains(t, stableBody, "1.0.0")
+		testastic.NotContains(t, stableBody, `class="routing"`)
+		testastic.Contains(t, body, "2.0.0-rc.1")
+		testastic.Contains(t, body, `<div class="routing">routed to canary by header x-canary: always</div>`)
+	})
+
+	t.Run("canary toggle on a target without canary routing says so", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a target without canary routing
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: the user turns on "route me to canary"
+		resp := httpGet(t, frontend.URL+"/tiles?count=1&canary=true")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the tiles explain that the toggle has no effect
+		testastic.Contains(t, readBody(t, resp), "canary routing is not configured for target default")
+	})
+}
@@ -26,6 +26,7 @@ func TestFrontendHandler(t *testing.T) {
 		defer backend.Close()
 
 		frontend, err := testutil.NewTestServer(
+			t.Context(),
 			backend.URL()+"/instance/info",
 			defaultTileColors,
 			templatesPath(),
@@ -52,6 +53,7 @@ func TestFrontendHandler(t *testing.T) {
 		defer backend.Close()
 
 		frontend, err := testutil.NewTestServer(
+			t.Context(),
 			backend.URL()+"/instance/info",
 			defaultTileColors,
 			templatesPath(),
@@ -78,6 +80,7 @@ func TestFrontendHandler(t *testing.T) {
 		defer backend.Close()
 
 		frontend, err := testutil.NewTestServer(
+			t.Context(),
 			backend.URL()+"/instance/info",
 			defaultTileColors,
 			templatesPath(),
@@ -108,6 +111,7 @@ func TestFrontendTiles(t *testing.T) {
 		defer backend.Close()
 
 		frontend, err := testutil.NewTestServer(
+			t.Context(),
 			backend.URL()+"/instance/info",
 			[]string{"#667eea", "#f093fb"},
 			templatesPath(),
@@ -134,6 +138,7 @@ func TestFrontendTiles(t *testing.T) {
 		defer backend.Close()
 
 		frontend, err := testutil.NewTestServer(
+			t.Context(),
 			backend.URL()+"/instance/info",
 			defaultTileColors,
 			templatesPath(),
@@ -160,6 +165,7 @@ func TestFrontendTiles(t *testing.T) {
 		defer backend.Close()
 
 		frontend, err := testutil.NewTestServer(
+			t.Context(),
 			backend.URL()+"/instance/info",
 			defaultTileColors,
 			templatesPath(),
@@ -190,6 +196,7 @@ func TestFrontendTiles(t *testing.T) {
 		defer backend.Close()
 
 		frontend, err := testutil.NewTestServer(
+			t.Context(),
 			backend.URL()+"/instance/info",
 			defaultTileColors,
 			templatesPath(),
@@ -215,6 +222,7 @@ func TestFrontendTiles(t *testing.T) {
 
 		// GIVEN: a frontend server with unreachable backend
 		frontend, err := testutil.NewTestServer(
+			t.Context(),
 			"http://localhost:59999/instance/info",
 			defaultTileColors,
 			templatesPath(),
@@ -234,6 +242,67 @@ func TestFrontendTiles(t *testing.T) {
 	})
 }
 
+func TestAdaptiveSampling(t *testing.T) {
+	t.Parallel()
+
+	t.Run("samples until no new instances appear", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a load-balanced backend with three instances
+		backend := newMockBackendWithHostnames("1.0.0", "pod-a", "pod-b", "pod-c")
+		defer backend.Close()
+
+		frontend, err := testutil.NewTestServer(
+			t.Context(),
+			backend.URL()+"/instance/info",
+			defaultTileColors,
+			templatesPath(),
+			testutil.NewTestLogger(t),
+		)
+		testastic.NoError(t, err)
+
+		defer frontend.Close()
+
+		// WHEN: requesting tiles in adaptive mode with a patience of 5 samples
+		resp := httpGet(t, frontend.URL+"/tiles?mode=adaptive&patience=5")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: sampling stops 5 samples after the last new instance and reports coverage
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+		testastic.AssertHTML(t, testdataPath("frontend_tiles_adaptive", "expected_response.html"), resp.Body)
+	})
+
+	t.Run("stops when the sample budget is spent", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a load-balanced backend with more instances than the budget allows to find
+		backend := newMockBackendWithHostnames("1.0.0", "pod-a", "pod-b", "pod-c", "pod-d", "pod-e")
+		defer backend.Close()
+
+		frontend, err := testutil.NewTestServer(
+			t.Context(),
+			backend.URL()+"/instance/info",
+			defaultTileColors,
+			templatesPath(),
+			testutil.NewTestLogger(t),
+		)
+		testastic.NoError(t, err)
+
+		defer frontend.Close()
+
+		// WHEN: requesting tiles in adaptive mode with a budget of 4 samples
+		resp := httpGet(t, frontend.URL+"/tiles?mode=adaptive&budget=4")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: sampling stops at the budget and estimates more instances than were seen
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body := readBody(t, resp)
+		testastic.Contains(t, body, "saw 4 of ~10 instances")
+		testastic.Contains(t, body, "sample budget of 4 exhausted")
+	})
+}
+
 func readBody(t *testing.T, resp *http.Response) string {
 	t.Helper()
 
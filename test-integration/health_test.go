@@ -0,0 +1,137 @@
+package integration_test
+
+import (
+	"net/http"
+	"phasor-frontend/internal/config"
+	"phasor-frontend/testutil"
+	"testing"
+	"time"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestBackendHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("failing backend makes frontend not ready by default", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend server connected to an unhealthy backend
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.SetHealthy(false)
+
+		frontend, err := testutil.NewTestServer(
+			t.Context(),
+			backend.URL()+"/instance/info",
+			defaultTileColors,
+			templatesPath(),
+			testutil.NewTestLogger(t),
+		)
+		testastic.NoError(t, err)
+
+		defer frontend.Close()
+
+		// WHEN: requesting the ready health endpoint
+		resp := httpGet(t, frontend.URL+"/health/ready")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the frontend reports itself as not ready
+		testastic.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		testastic.AssertJSON(t, testdataPath("frontend_health_ready_not_ready", "expected_response.json"), resp.Body)
+	})
+
+	t.Run("failing backend only degrades frontend with degraded policy", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend server with degraded failure policy and an unhealthy backend
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.SetHealthy(false)
+
+		cfg := &config.Config{
+			BackendURL:  backend.URL() + "/instance/info",
+			Environment: "test",
+			TileColors:  defaultTileColors,
+			HealthCheck: config.HealthCheckConfig{FailurePolicy: "degraded"},
+		}
+
+		frontend, err := testutil.NewTestServerWithConfig(t.Context(), cfg, templatesPath(), testutil.NewTestLogger(t))
+		testastic.NoError(t, err)
+
+		defer frontend.Close()
+
+		// WHEN: requesting the ready health endpoint
+		resp := httpGet(t, frontend.URL+"/health/ready")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the frontend stays ready and reports the backend as degraded
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+		testastic.AssertJSON(t, testdataPath("frontend_health_ready_degraded", "expected_response.json"), resp.Body)
+	})
+
+	t.Run("health check probes the url of the default target", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: an unhealthy backend url overridden by a default target on a healthy backend
+		unhealthy := newMockBackend("1.0.0")
+		defer unhealthy.Close()
+
+		unhealthy.SetHealthy(false)
+
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		cfg := &config.Config{
+			BackendURL:  unhealthy.URL() + "/instance/info",
+			Environment: "test",
+			TileColors:  defaultTileColors,
+			Targets:     []config.TargetConfig{{Name: config.DefaultTargetName, URL: backend.URL() + "/instance/info"}},
+		}
+
+		frontend, err := testutil.NewTestServerWithConfig(t.Context(), cfg, templatesPath(), testutil.NewTestLogger(t))
+		testastic.NoError(t, err)
+
+		defer frontend.Close()
+
+		// WHEN: requesting the ready health endpoint
+		resp := httpGet(t, frontend.URL+"/health/ready")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the frontend is ready, because the target it samples is healthy
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("backend health is refreshed in the background", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend server with a short health check interval and a healthy backend
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		cfg := &config.Config{
+			BackendURL:  backend.URL() + "/instance/info",
+			Environment: "test",
+			TileColors:  defaultTileColors,
+			HealthCheck: config.HealthCheckConfig{Interval: 20 * time.Millisecond},
+		}
+
+		frontend, err := testutil.NewTestServerWithConfig(t.Context(), cfg, templatesPath(), testutil.NewTestLogger(t))
+		testastic.NoError(t, err)
+
+		defer frontend.Close()
+
+		// WHEN: the backend becomes unhealthy
+		backend.SetHealthy(false)
+
+		// THEN: the cached result eventually reports the frontend as not ready
+		testastic.Eventually(t, func() bool {
+			resp := httpGet(t, frontend.URL+"/health/ready")
+			_ = resp.Body.Close()
+
+			return resp.StatusCode == http.StatusServiceUnavailable
+		}, 2*time.Second)
+	})
+}
@@ -0,0 +1,144 @@
+package integration_test
+
+import (
+	"net/http"
+	"phasor-frontend/internal/config"
+	"regexp"
+	"testing"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestInstanceDetail(t *testing.T) {
+	t.Parallel()
+
+	t.Run("detail shows the sample history of a host", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: two hosts answering with their zone header, sampled twice each
+		backend := newMockBackendWithHostnames("1.0.0", "web-1", "web-2")
+		defer backend.Close()
+
+		backend.SetResponseHeaders(func(hostname string) http.Header {
+			return http.Header{"X-Zone": {"zone-" + hostname}}
+		})
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name:           config.DefaultTargetName,
+				CaptureHeaders: []string{"x-zone"},
+				PinHeader:      "x-pin-host",
+			}}
+		})
+		defer frontend.Close()
+
+		tiles := httpGet(t, frontend.URL+"/tiles?count=4")
+		_ = tiles.Body.Close()
+
+		// WHEN: the drawer and the page of web-1 are opened
+		drawer := htmxGet(t, frontend.URL+"/instances/web-1", "")
+		defer drawer.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		page := httpGet(t, frontend.URL+"/instances/web-1")
+		defer page.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the drawer holds the history of web-1 only, and the page wraps the same detail
+		body := readBody(t, drawer)
+		testastic.NotContains(t, body, "<title>")
+		testastic.Contains(t, body, "<h2>web-1</h2>")
+		testastic.Contains(t, body, `<div class="errors">0 errors in 2 samples</div>`)
+		testastic.Contains(t, body, `<div class="version">default 1.0.0 samples=2 errors=0</div>`)
+		testastic.Contains(t, body, `<div class="header">x-zone: zone-web-1</div>`)
+		testastic.Contains(t, body, `<div class="hunt-target">default</div>`)
+		testastic.NotContains(t, body, "web-2")
+		testastic.True(t, regexp.MustCompile(`<div class="latency">p50=\S+ p90=\S+ p99=\S+</div>`).MatchString(body))
+
+		testastic.Contains(t, readBody(t, page), "<title>Instance Detail</title>")
+	})
+
+	t.Run("unknown host is not found", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend that has not sampled anything
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: opening the detail of a host
+		resp := httpGet(t, frontend.URL+"/instances/test-host")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the host is not found
+		testastic.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("tile links to the detail of a hostname with a space", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a host whose name contains a space
+		backend := newMockBackendWithHostname("1.0.0", "web 1")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: requesting a tile and following its detail link
+		tiles := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer tiles.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		tilesBody := readBody(t, tiles)
+
+		detail := htmxGet(t, frontend.URL+"/instances/web%201", "")
+		defer detail.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the hostname is escaped as a path segment and the link opens its detail
+		testastic.Contains(t, tilesBody, `hx-get="/instances/web%201"`)
+		testastic.Equal(t, http.StatusOK, detail.StatusCode)
+		testastic.Contains(t, readBody(t, detail), "<h2>web 1</h2>")
+	})
+
+	t.Run("hunt samples with the pin header until the host answers", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: three hosts behind a load balancer that ignores the pin header
+		backend := newMockBackendWithHostnames("1.0.0", "web-1", "web-2", "web-3")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{Name: config.DefaultTargetName, PinHeader: "x-pin-host"}}
+		})
+		defer frontend.Close()
+
+		// WHEN: hunting for web-3
+		resp := htmxGet(t, frontend.URL+"/instances/web-3/hunt?target=default", "")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: web-3 answered the third sample, which asked for it, and is now in its history
+		body := readBody(t, resp)
+		testastic.Contains(t, body, `<div class="hunt">found after 3 samples</div>`)
+		testastic.Contains(t, body, `<div class="errors">0 errors in 1 samples</div>`)
+		testastic.Equal(t, "web-3", backend.LastHeader().Get("X-Pin-Host"))
+	})
+
+	t.Run("hunt is refused without host pinning", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a target without a pin header
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: hunting for a host
+		resp := httpGet(t, frontend.URL+"/instances/test-host/hunt")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the request is refused and the backend is not sampled
+		testastic.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		testastic.Contains(t, readBody(t, resp), "target default does not pin hosts")
+		testastic.Equal(t, uint64(0), backend.Requests())
+	})
+}
@@ -0,0 +1,113 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"phasor-frontend/internal/config"
+	"phasor-frontend/testutil"
+	"testing"
+	"time"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestInstanceInventory(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sampled instances are listed as JSON", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend that sampled a backend with two instances
+		backend := newMockBackendWithHostnames("1.0.0", "pod-a", "pod-b")
+		defer backend.Close()
+
+		frontend, err := testutil.NewTestServer(
+			t.Context(),
+			backend.URL()+"/instance/info",
+			defaultTileColors,
+			templatesPath(),
+			testutil.NewTestLogger(t),
+		)
+		testastic.NoError(t, err)
+
+		defer frontend.Close()
+
+		tiles := httpGet(t, frontend.URL+"/tiles?count=3")
+		_ = tiles.Body.Close()
+
+		// WHEN: requesting the inventory as JSON
+		resp := httpGet(t, frontend.URL+"/instances.json")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: both instances are listed with their sample counts
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+		testastic.AssertJSON(t, testdataPath("frontend_instances_json", "expected_response.json"), resp.Body)
+	})
+
+	t.Run("instances not seen recently are marked stale", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend with an immediate stale threshold that sampled two instances
+		backend := newMockBackendWithHostnames("1.0.0", "pod-a", "pod-b")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Inventory.StaleAfter = time.Nanosecond
+		})
+		defer frontend.Close()
+
+		tiles := httpGet(t, frontend.URL+"/tiles?count=3")
+		_ = tiles.Body.Close()
+
+		// WHEN: requesting the inventory page
+		resp := httpGet(t, frontend.URL+"/instances")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: both instances are shown as stale
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+		testastic.AssertHTML(t, testdataPath("frontend_instances_page", "expected_response.html"), resp.Body)
+	})
+
+	t.Run("instances not seen within the expiry are removed", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend with a short expiry that sampled an instance
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Inventory.ExpireAfter = 50 * time.Millisecond
+		})
+		defer frontend.Close()
+
+		tiles := httpGet(t, frontend.URL+"/tiles?count=1")
+		_ = tiles.Body.Close()
+
+		// WHEN: no further samples are taken
+		// THEN: the instance eventually disappears from the inventory
+		testastic.Eventually(t, func() bool {
+			return len(fetchInventory(t, frontend.URL).Instances) == 0
+		}, 2*time.Second)
+	})
+}
+
+type inventoryResponse struct {
+	Instances []struct {
+		Hostname string `json:"hostname"`
+		Restarts int    `json:"restarts"`
+	} `json:"instances"`
+}
+
+func fetchInventory(t *testing.T, frontendURL string) inventoryResponse {
+	t.Helper()
+
+	resp := httpGet(t, frontendURL+"/instances.json")
+	defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+	var inventory inventoryResponse
+
+	err := json.NewDecoder(resp.Body).Decode(&inventory)
+	testastic.NoError(t, err)
+
+	return inventory
+}
@@ -0,0 +1,208 @@
+package integration_test
+
+import (
+	"context"
+	"net/http"
+	"phasor-frontend/internal/config"
+	"testing"
+	"time"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestRateLimits(t *testing.T) {
+	t.Parallel()
+
+	t.Run("client over its rate limit is rejected with retry-after", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend that allows a single tile request per client and rejects the rest
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.RateLimit = config.RateLimitConfig{
+				ClientRequestsPerSecond: 0.01,
+				OnLimit:                 config.OnLimitReject,
+			}
+		})
+		defer frontend.Close()
+
+		first := httpGet(t, frontend.URL+"/tiles?count=1")
+		_ = first.Body.Close()
+
+		// WHEN: the client requests tiles again
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the request is rejected until the next token is available
+		testastic.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		testastic.Equal(t, "100", resp.Header.Get("Retry-After"))
+	})
+
+	t.Run("client over its rate limit gets cached tiles", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend that allows a single tile request per client and degrades the rest
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.RateLimit.ClientRequestsPerSecond = 0.01
+		})
+		defer frontend.Close()
+
+		first := httpGet(t, frontend.URL+"/tiles?count=2")
+		_ = first.Body.Close()
+
+		// WHEN: the client requests tiles again
+		resp := httpGet(t, frontend.URL+"/tiles?count=2")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the tiles of the first request are shown without sampling the backend again
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body := readBody(t, resp)
+		testastic.Contains(t, body, `<div class="degraded">client rate limit reached, showing samples from 0s ago</div>`)
+		testastic.Contains(t, body, "saw 1 of ~1 instances")
+		testastic.Equal(t, uint64(2), backend.Requests())
+	})
+
+	t.Run("cached tiles are shared across sampling effort", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend that allows a single tile request per client and degrades the rest
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.RateLimit.ClientRequestsPerSecond = 0.01
+		})
+		defer frontend.Close()
+
+		first := httpGet(t, frontend.URL+"/tiles?count=2")
+		_ = first.Body.Close()
+
+		// WHEN: the client requests the same view with a different timeout
+		resp := httpGet(t, frontend.URL+"/tiles?count=2&timeout=5s")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the timeout does not change the view, so its cached tiles are shown
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+		testastic.Contains(t, readBody(t, resp), `<div class="degraded">client rate limit reached`)
+	})
+
+	t.Run("requests canceled while waiting for a sample slot keep their tokens", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend with one sample slot and two sample tokens, and a slow sample holding the slot
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.DelayNext(1, 300*time.Millisecond)
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.RateLimit = config.RateLimitConfig{SamplesPerSecond: 0.01, SampleBurst: 2, MaxConcurrentSamples: 1}
+		})
+		defer frontend.Close()
+
+		slow := make(chan struct{})
+
+		go func() {
+			defer close(slow)
+
+			resp := httpGet(t, frontend.URL+"/tiles?count=1")
+			_ = resp.Body.Close()
+		}()
+
+		time.Sleep(50 * time.Millisecond)
+
+		canceled := httpGet(t, frontend.URL+"/tiles?count=1&timeout=50ms")
+		_ = canceled.Body.Close()
+
+		<-slow
+
+		// WHEN: requesting a tile once the slot is free
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the request that gave up did not spend the second token
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+		testastic.NotContains(t, readBody(t, resp), `class="degraded"`)
+		testastic.Equal(t, uint64(2), backend.Requests())
+	})
+
+	t.Run("cached tiles are not shared across forwarded headers", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend that forwards the tenant header and degrades requests over the client limit,
+		// with tiles cached for tenant a
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.RateLimit.ClientRequestsPerSecond = 0.01
+			cfg.Targets = []config.TargetConfig{{Name: config.DefaultTargetName, ForwardHeaders: []string{"x-tenant"}}}
+		})
+		defer frontend.Close()
+
+		first := tenantGet(t, frontend.URL+"/tiles?count=1", "a")
+		_ = first.Body.Close()
+
+		// WHEN: the same client requests tiles for tenant b
+		resp := tenantGet(t, frontend.URL+"/tiles?count=1", "b")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the tiles of tenant a are not shown, and the request is rejected instead
+		testastic.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		testastic.NotContains(t, readBody(t, resp), `class="degraded"`)
+		testastic.Equal(t, uint64(1), backend.Requests())
+	})
+
+	t.Run("exhausted global sample budget is rejected and reported in metrics", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend that allows two outgoing samples and has nothing cached
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.RateLimit = config.RateLimitConfig{
+				SamplesPerSecond: 0.01,
+				SampleBurst:      2,
+			}
+		})
+		defer frontend.Close()
+
+		// WHEN: requesting three tiles
+		resp := httpGet(t, frontend.URL+"/tiles?count=3")
+		_ = resp.Body.Close()
+
+		// THEN: the request is rejected and the limit shows up in the metrics
+		testastic.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		testastic.Equal(t, uint64(2), backend.Requests())
+
+		metrics := httpGet(t, frontend.URL+"/metrics")
+		defer metrics.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		body := readBody(t, metrics)
+		testastic.Contains(t, body, `phasor_frontend_rate_limited_total{limit="global"} 1`)
+		testastic.Contains(t, body, `phasor_frontend_samples_total{result="ok"} 2`)
+		testastic.Contains(t, body, `phasor_frontend_samples_total{result="rate_limited"} 1`)
+	})
+}
+
+// tenantGet requests url with the x-tenant header set to tenant.
+func tenantGet(t *testing.T, url, tenant string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	testastic.NoError(t, err)
+
+	req.Header.Set("X-Tenant", tenant)
+
+	resp, err := http.DefaultClient.Do(req)
+	testastic.NoError(t, err)
+
+	return resp
+}
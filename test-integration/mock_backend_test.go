@@ -1,9 +1,13 @@
 package integration_test
 
 import (
+	"crypto/tls"
 	"encoding/json"
+	"hash/fnv"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,25 +16,93 @@ import (
 type mockBackendServer struct {
 	server    *httptest.Server
 	version   string
-	hostname  string
-	startTime time.Time
+	hostnames []string
+	requests  atomic.Uint64
+	startTime atomic.Pointer[time.Time]
+	clockSkew atomic.Int64
+	failNext  atomic.Int64
+	slowNext  atomic.Int64
+	slowDelay atomic.Int64
+	unhealthy atomic.Bool
+	authorize atomic.Pointer[func(*http.Request) bool]
+	canary    atomic.Pointer[canaryRoute]
+	header    atomic.Pointer[http.Header]
+	respond   atomic.Pointer[func(hostname string) http.Header]
+	sticky    atomic.Pointer[stickyRoute]
+	delayOne  atomic.Pointer[requestDelay]
+	versions  atomic.Pointer[map[string]string]
+}
+
+// requestDelay delays the instance info request with the given index.
+type requestDelay struct {
+	request uint64
+	delay   time.Duration
+}
+
+// stickyRoute pins sessions to a hostname, by cookie or by the hash of an affinity header.
+type stickyRoute struct {
+	cookie string
+	header string
+}
+
+// canaryRoute answers requests that match with the version of a canary.
+type canaryRoute struct {
+	version string
+	matches func(*http.Request) bool
 }
 
 func newMockBackend(version string) *mockBackendServer {
+	return newMockBackendWithHostname(version, "test-host")
+}
+
+func newMockBackendWithHostname(version, hostname string) *mockBackendServer {
+	return newMockBackendWithHostnames(version, hostname)
+}
+
+// newMockBackendWithHostnames creates a mock backend that answers with the given
+// hostnames in round-robin order, like a load balancer in front of several pods.
+func newMockBackendWithHostnames(version string, hostnames ...string) *mockBackendServer {
 	m := &mockBackendServer{
 		version:   version,
-		hostname:  "test-host",
-		startTime: time.Now(),
+		hostnames: hostnames,
 	}
+	m.SetUptime(0)
+
+	m.server = httptest.NewServer(m.routes())
+
+	return m
+}
+
+// newMockTLSBackend creates a mock backend that serves HTTPS with the given TLS configuration.
+func newMockTLSBackend(version string, tlsConfig *tls.Config) *mockBackendServer {
+	m := &mockBackendServer{
+		version:   version,
+		hostnames: []string{"test-host"},
+	}
+	m.SetUptime(0)
+
+	m.server = httptest.NewUnstartedServer(m.routes())
+	m.server.TLS = tlsConfig
+	m.server.StartTLS()
+
+	return m
+}
 
+func (m *mockBackendServer) routes() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/instance/info", m.instanceInfoHandler)
 	mux.HandleFunc("/health/ready", m.healthReadyHandler)
 	mux.HandleFunc("/health/live", m.healthLiveHandler)
 
-	m.server = httptest.NewServer(mux)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authorize := m.authorize.Load(); authorize != nil && !(*authorize)(r) {
+			w.WriteHeader(http.StatusUnauthorized)
 
-	return m
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	})
 }
 
 func (m *mockBackendServer) URL() string {
@@ -41,10 +113,142 @@ func (m *mockBackendServer) Close() {
 	m.server.Close()
 }
 
+// RequireAuth makes the mock answer 401 Unauthorized to requests that authorize rejects.
+func (m *mockBackendServer) RequireAuth(authorize func(*http.Request) bool) {
+	m.authorize.Store(&authorize)
+}
+
+// SetHealthy controls whether the readiness endpoint of the mock reports success.
+func (m *mockBackendServer) SetHealthy(healthy bool) {
+	m.unhealthy.Store(!healthy)
+}
+
+// SetUptime makes the mock report the given uptime, growing from now on.
+func (m *mockBackendServer) SetUptime(uptime time.Duration) {
+	startTime := time.Now().Add(-uptime)
+	m.startTime.Store(&startTime)
+}
+
+// SetClockSkew makes the mock report timestamps that are ahead of the real time by skew.
+func (m *mockBackendServer) SetClockSkew(skew time.Duration) {
+	m.clockSkew.Store(int64(skew))
+}
+
+// FailNext makes the next n instance info requests fail with 503 Service Unavailable.
+func (m *mockBackendServer) FailNext(n int) {
+	m.failNext.Store(int64(n))
+}
+
+// DelayNext delays the answer to the next n instance info requests by delay.
+func (m *mockBackendServer) DelayNext(n int, delay time.Duration) {
+	m.slowDelay.Store(int64(delay))
+	m.slowNext.Store(int64(n))
+}
+
+// DelayRequest delays the answer to the instance info request with the given index,
+// counting from zero, by delay.
+func (m *mockBackendServer) DelayRequest(request uint64, delay time.Duration) {
+	m.delayOne.Store(&requestDelay{request: request, delay: delay})
+}
+
+// SetCanary makes the mock answer with version to instance info requests that match,
+// like a mesh that routes them to a canary.
+func (m *mockBackendServer) SetCanary(version string, matches func(*http.Request) bool) {
+	m.canary.Store(&canaryRoute{version: version, matches: matches})
+}
+
+// SetHostVersions makes the listed hostnames answer with their own version, like a
+// rollout in progress.
+func (m *mockBackendServer) SetHostVersions(versions map[string]string) {
+	m.versions.Store(&versions)
+}
+
+// SetResponseHeaders makes the mock add the headers returned by headers to the instance
+// info responses of every hostname.
+func (m *mockBackendServer) SetResponseHeaders(headers func(hostname string) http.Header) {
+	m.respond.Store(&headers)
+}
+
+// SetStickyCookie makes the mock pin clients to the hostname stored in cookie, which it
+// sets on the first response, like an ingress with cookie-based session affinity.
+func (m *mockBackendServer) SetStickyCookie(cookie string) {
+	m.sticky.Store(&stickyRoute{cookie: cookie})
+}
+
+// SetAffinityHeader makes the mock pin clients to a hostname by the hash of header.
+func (m *mockBackendServer) SetAffinityHeader(header string) {
+	m.sticky.Store(&stickyRoute{header: header})
+}
+
+// LastHeader returns the headers of the most recent instance info request.
+func (m *mockBackendServer) LastHeader() http.Header {
+	header := m.header.Load()
+	if header == nil {
+		return nil
+	}
+
+	return *header
+}
+
+// Requests returns the number of instance info requests served so far.
+func (m *mockBackendServer) Requests() uint64 {
+	return m.requests.Load()
+}
+
 //nolint:errchkjson // Test helper, error handling not critical.
-func (m *mockBackendServer) instanceInfoHandler(w http.ResponseWriter, _ *http.Request) {
+func (m *mockBackendServer) instanceInfoHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	request := m.requests.Add(1) - 1
+
+	header := r.Header.Clone()
+	m.header.Store(&header)
+
+	version := m.version
+	if canary := m.canary.Load(); canary != nil && canary.matches(r) {
+		version = canary.version
+	}
+
+	if m.failNext.Add(-1) >= 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+
+		return
+	}
+
+	if m.slowNext.Add(-1) >= 0 {
+		select {
+		case <-time.After(time.Duration(m.slowDelay.Load())):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if delay := m.delayOne.Load(); delay != nil && delay.request == request {
+		select {
+		case <-time.After(delay.delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	hostname := m.hostnames[request%uint64(len(m.hostnames))]
+
+	if sticky := m.sticky.Load(); sticky != nil {
+		hostname = sticky.hostname(w, r, m.hostnames, hostname)
+	}
+
+	if versions := m.versions.Load(); versions != nil {
+		if hostVersion, found := (*versions)[hostname]; found {
+			version = hostVersion
+		}
+	}
+
+	if respond := m.respond.Load(); respond != nil {
+		for name, values := range (*respond)(hostname) {
+			w.Header()[name] = values
+		}
+	}
+
 	resp := struct {
 		Version   string `json:"version"`
 		Hostname  string `json:"hostname"`
@@ -52,11 +256,11 @@ func (m *mockBackendServer) instanceInfoHandler(w http.ResponseWriter, _ *http.R
 		GoVersion string `json:"go_version"`
 		Timestamp string `json:"timestamp"`
 	}{
-		Version:   m.version,
-		Hostname:  m.hostname,
-		Uptime:    time.Since(m.startTime).String(),
+		Version:   version,
+		Hostname:  hostname,
+		Uptime:    time.Since(*m.startTime.Load()).String(),
 		GoVersion: "go1.25.5",
-		Timestamp: time.Now().Format(time.RFC3339Nano),
+		Timestamp: time.Now().Add(time.Duration(m.clockSkew.Load())).Format(time.RFC3339Nano),
 	}
 
 	_ = json.NewEncoder(w).Encode(resp)
@@ -66,6 +270,13 @@ func (m *mockBackendServer) instanceInfoHandler(w http.ResponseWriter, _ *http.R
 func (m *mockBackendServer) healthReadyHandler(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	if m.unhealthy.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "error"})
+
+		return
+	}
+
 	resp := map[string]string{
 		"status": "ok",
 	}
@@ -83,3 +294,26 @@ func (m *mockBackendServer) healthLiveHandler(w http.ResponseWriter, _ *http.Req
 
 	_ = json.NewEncoder(w).Encode(resp)
 }
+
+// hostname returns the hostname the session of the request is pinned to, pinning new
+// cookie sessions to fallback.
+func (s *stickyRoute) hostname(w http.ResponseWriter, r *http.Request, hostnames []string, fallback string) string {
+	if s.header != "" {
+		if value := r.Header.Get(s.header); value != "" {
+			hash := fnv.New32a()
+			_, _ = hash.Write([]byte(value))
+
+			return hostnames[hash.Sum32()%uint32(len(hostnames))]
+		}
+
+		return fallback
+	}
+
+	if cookie, err := r.Cookie(s.cookie); err == nil && slices.Contains(hostnames, cookie.Value) {
+		return cookie.Value
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: s.cookie, Value: fallback, Path: "/"})
+
+	return fallback
+}
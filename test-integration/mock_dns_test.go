@@ -0,0 +1,172 @@
+package integration_test
+
+import (
+	"net"
+	"net/netip"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/monkescience/testastic"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const mockDNSTTL = 5
+
+// mockSRVRecord is a single SRV answer served by the mock DNS server.
+type mockSRVRecord struct {
+	target string
+	port   uint16
+}
+
+// mockDNSServer is a stub DNS resolver answering A and SRV queries over UDP.
+type mockDNSServer struct {
+	conn net.PacketConn
+
+	mu   sync.RWMutex
+	a    map[string][]netip.Addr
+	srv  map[string][]mockSRVRecord
+	done chan struct{}
+}
+
+func newMockDNS(t *testing.T) *mockDNSServer {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	testastic.NoError(t, err)
+
+	m := &mockDNSServer{
+		conn: conn,
+		a:    make(map[string][]netip.Addr),
+		srv:  make(map[string][]mockSRVRecord),
+		done: make(chan struct{}),
+	}
+
+	go m.serve()
+
+	return m
+}
+
+func (m *mockDNSServer) Addr() string {
+	return m.conn.LocalAddr().String()
+}
+
+func (m *mockDNSServer) Close() {
+	_ = m.conn.Close()
+	<-m.done
+}
+
+// SetA replaces the A records of name.
+func (m *mockDNSServer) SetA(name string, addrs ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.a[name] = nil
+	for _, addr := range addrs {
+		m.a[name] = append(m.a[name], netip.MustParseAddr(addr))
+	}
+}
+
+// SetSRV replaces the SRV records of name.
+func (m *mockDNSServer) SetSRV(name string, records ...mockSRVRecord) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.srv[name] = records
+}
+
+func (m *mockDNSServer) serve() {
+	defer close(m.done)
+
+	buf := make([]byte, 4096)
+
+	for {
+		n, addr, err := m.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		var query dnsmessage.Message
+
+		err = query.Unpack(buf[:n])
+		if err != nil || len(query.Questions) == 0 {
+			continue
+		}
+
+		answer := m.answer(query)
+
+		response, err := answer.Pack()
+		if err != nil {
+			continue
+		}
+
+		_, _ = m.conn.WriteTo(response, addr)
+	}
+}
+
+func (m *mockDNSServer) answer(query dnsmessage.Message) dnsmessage.Message {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	question := query.Questions[0]
+	name := question.Name.String()
+
+	response := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: query.ID, Response: true, Authoritative: true},
+		Questions: query.Questions,
+	}
+
+	switch question.Type {
+	case dnsmessage.TypeA:
+		response.Answers = m.aResources(name)
+	case dnsmessage.TypeSRV:
+		for _, record := range m.srv[name] {
+			response.Answers = append(response.Answers, dnsmessage.Resource{
+				Header: resourceHeader(name, dnsmessage.TypeSRV),
+				Body: &dnsmessage.SRVResource{
+					Target: dnsmessage.MustNewName(record.target),
+					Port:   record.port,
+				},
+			})
+			response.Additionals = append(response.Additionals, m.aResources(record.target)...)
+		}
+	default:
+	}
+
+	return response
+}
+
+func (m *mockDNSServer) aResources(name string) []dnsmessage.Resource {
+	resources := make([]dnsmessage.Resource, 0, len(m.a[name]))
+
+	for _, addr := range m.a[name] {
+		resources = append(resources, dnsmessage.Resource{
+			Header: resourceHeader(name, dnsmessage.TypeA),
+			Body:   &dnsmessage.AResource{A: addr.As4()},
+		})
+	}
+
+	return resources
+}
+
+func resourceHeader(name string, recordType dnsmessage.Type) dnsmessage.ResourceHeader {
+	return dnsmessage.ResourceHeader{
+		Name:  dnsmessage.MustNewName(name),
+		Type:  recordType,
+		Class: dnsmessage.ClassINET,
+		TTL:   mockDNSTTL,
+	}
+}
+
+// hostPort splits the host and port of a mock server URL.
+func hostPort(t *testing.T, rawURL string) (string, int) {
+	t.Helper()
+
+	host, portStr, err := net.SplitHostPort(rawURL[len("http://"):])
+	testastic.NoError(t, err)
+
+	port, err := strconv.Atoi(portStr)
+	testastic.NoError(t, err)
+
+	return host, port
+}
@@ -0,0 +1,136 @@
+package integration_test
+
+import (
+	"context"
+	"net/http"
+	"phasor-frontend/testutil"
+	"testing"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestPermalinks(t *testing.T) {
+	t.Parallel()
+
+	t.Run("index page restores the view of a permalink", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend server connected to a backend
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend, err := testutil.NewTestServer(
+			t.Context(),
+			backend.URL()+"/instance/info",
+			defaultTileColors,
+			templatesPath(),
+			testutil.NewTestLogger(t),
+		)
+		testastic.NoError(t, err)
+
+		defer frontend.Close()
+
+		// WHEN: a permalink with view parameters and an unknown one is opened
+		resp := httpGet(t, frontend.URL+"/?count=7&target=default&mode=sticky&sort=version&canary=true&palette=okabe_ito&bogus=x")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the controls and the tiles request carry the view, without the unknown parameter
+		body := readBody(t, resp)
+		testastic.Contains(t, body, "<p>Count: 7</p>")
+		testastic.Contains(t, body, "<p>Target: default</p>")
+		testastic.Contains(t, body, "<p>Mode: sticky</p>")
+		testastic.Contains(t, body, "<p>Sort: version</p>")
+		testastic.Contains(t, body, "<p>Canary: on</p>")
+		testastic.Contains(t, body,
+			`hx-get="/tiles?canary=true&amp;count=7&amp;mode=sticky&amp;palette=okabe_ito&amp;sort=version&amp;target=default"`)
+	})
+
+	t.Run("refresh interval that is not offered is kept as a custom choice", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend server connected to a backend
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: permalinks with an offered and a custom refresh interval are opened
+		offered := httpGet(t, frontend.URL+"/?refresh=30s")
+		defer offered.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		custom := httpGet(t, frontend.URL+"/?refresh=20s")
+		defer custom.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: only the interval that is not offered is rendered as a custom choice
+		testastic.Contains(t, readBody(t, offered), "<p>Refresh: 30s</p>")
+		testastic.Contains(t, readBody(t, custom), "<p>Refresh: 20s (custom)</p>")
+	})
+
+	t.Run("changing the view pushes its permalink", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend server connected to a backend
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend, err := testutil.NewTestServer(
+			t.Context(),
+			backend.URL()+"/instance/info",
+			defaultTileColors,
+			templatesPath(),
+			testutil.NewTestLogger(t),
+		)
+		testastic.NoError(t, err)
+
+		defer frontend.Close()
+
+		// WHEN: the controls and the initial load of the index page request tiles
+		changed := htmxGet(t, frontend.URL+"/tiles?count=2&mode=adaptive&group=X-Zone&sort=latency", "update")
+		defer changed.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		loaded := htmxGet(t, frontend.URL+"/tiles?count=2", "tiles-container")
+		defer loaded.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: only the change pushes the permalink of the view
+		testastic.Equal(t, "/?count=2&group=x-zone&mode=adaptive&sort=latency", changed.Header.Get("HX-Push-Url"))
+		testastic.Equal(t, "", loaded.Header.Get("HX-Push-Url"))
+	})
+
+	t.Run("sort parameter orders the tiles", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: three hosts in the middle of a rollout from 1.9.0 to 1.10.0
+		backend := newMockBackendWithHostnames("1.9.0", "web-1", "web-2", "web-3")
+		defer backend.Close()
+
+		backend.SetHostVersions(map[string]string{"web-1": "1.10.0", "web-3": "1.10.0"})
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: requesting the tiles of a permalink sorted by version
+		resp := httpGet(t, frontend.URL+"/tiles?count=3&sort=version")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the newest version comes first, then the hostnames in descending order
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+		testastic.AssertHTML(t, testdataPath("frontend_tiles_sort_version", "expected_response.html"), resp.Body)
+	})
+}
+
+// htmxGet requests url like htmx does when the element with the given id triggers it.
+func htmxGet(t *testing.T, url, trigger string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	testastic.NoError(t, err)
+
+	req.Header.Set("HX-Request", "true")
+	req.Header.Set("HX-Trigger", trigger)
+
+	resp, err := http.DefaultClient.Do(req)
+	testastic.NoError(t, err)
+
+	return resp
+}
@@ -0,0 +1,102 @@
+package integration_test
+
+import (
+	"phasor-frontend/internal/config"
+	"testing"
+	"time"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestAutoRefresh(t *testing.T) {
+	t.Parallel()
+
+	t.Run("refresh below the minimum is raised to it", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend with a minimum refresh interval of 20 seconds
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Refresh.MinInterval = 20 * time.Second
+		})
+		defer frontend.Close()
+
+		// WHEN: requesting tiles that refresh every 2 seconds
+		resp := httpGet(t, frontend.URL+"/tiles?count=1&refresh=2s")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the tiles poll the same view at the minimum interval
+		testastic.Equal(t, "20", resp.Header.Get("X-Refresh-Interval"))
+
+		body := readBody(t, resp)
+		testastic.Contains(t, body,
+			`<div class="refresh" hx-get="/tiles?count=1&amp;refresh=2s" hx-trigger="every 20s">every 20s, raised to minimum</div>`)
+	})
+
+	t.Run("polls within the minimum interval share one sampling round", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend with the default minimum refresh interval that polled once
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		first := httpGet(t, frontend.URL+"/tiles?count=2&refresh=1s")
+		_ = first.Body.Close()
+
+		// WHEN: a client ignoring the interval polls again right away
+		resp := httpGet(t, frontend.URL+"/tiles?count=2&refresh=1s")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the backend was sampled by the first poll only
+		testastic.Contains(t, readBody(t, resp), "from cache")
+		testastic.Equal(t, uint64(2), backend.Requests())
+	})
+
+	t.Run("refresh slows down while the sample budget is exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend that allows a single outgoing sample
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.RateLimit = config.RateLimitConfig{
+				SamplesPerSecond: 0.01,
+				SampleBurst:      1,
+			}
+		})
+		defer frontend.Close()
+
+		// WHEN: requesting one tile that refreshes every 30 seconds
+		resp := httpGet(t, frontend.URL+"/tiles?count=1&refresh=30s")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the sample used up the budget and the server asks for half the refresh rate
+		testastic.Equal(t, "60", resp.Header.Get("X-Refresh-Interval"))
+		testastic.Contains(t, readBody(t, resp), "every 60s, slowed down</div>")
+	})
+
+	t.Run("tiles without refresh do not poll", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend server connected to a backend
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: requesting tiles without a refresh interval
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: neither the header nor the poller is rendered
+		testastic.Equal(t, "", resp.Header.Get("X-Refresh-Interval"))
+		testastic.NotContains(t, readBody(t, resp), `class="refresh"`)
+	})
+}
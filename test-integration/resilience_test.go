@@ -0,0 +1,208 @@
+package integration_test
+
+import (
+	"net/http"
+	"phasor-frontend/internal/config"
+	"testing"
+	"time"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestResilience(t *testing.T) {
+	t.Parallel()
+
+	t.Run("transient failures are retried without distorting the samples", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a backend that fails its next request and a target that retries twice
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.FailNext(1)
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name: config.DefaultTargetName,
+				Resilience: config.ResilienceConfig{
+					Retries:     2,
+					BackoffBase: time.Millisecond,
+				},
+			}}
+		})
+		defer frontend.Close()
+
+		// WHEN: requesting a single tile
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the sample succeeds and the retry is counted separately
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body := readBody(t, resp)
+		testastic.Contains(t, body, "saw 1 of ~1 instances")
+		testastic.Contains(t, body, `<div class="resilience">retries=1 hedges=0 short_circuited=0</div>`)
+	})
+
+	t.Run("slow requests are hedged", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a backend that answers its next request slowly and a target that hedges after 20ms
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.DelayNext(1, 2*time.Second)
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name: config.DefaultTargetName,
+				Resilience: config.ResilienceConfig{
+					HedgeAfter: 20 * time.Millisecond,
+				},
+			}}
+		})
+		defer frontend.Close()
+
+		// WHEN: requesting a single tile
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the hedged request answers the sample
+		body := readBody(t, resp)
+		testastic.Contains(t, body, "saw 1 of ~1 instances")
+		testastic.Contains(t, body, `<div class="resilience">retries=0 hedges=1 short_circuited=0</div>`)
+	})
+
+	t.Run("open circuit short-circuits samples of a failing backend", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a failing backend and a target whose circuit opens after two failed samples
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.FailNext(100)
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name: config.DefaultTargetName,
+				Resilience: config.ResilienceConfig{
+					BreakerThreshold: 2,
+					BreakerOpenFor:   time.Minute,
+				},
+			}}
+		})
+		defer frontend.Close()
+
+		// WHEN: requesting five tiles
+		resp := httpGet(t, frontend.URL+"/tiles?count=5")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: only the first two samples reach the backend
+		body := readBody(t, resp)
+		testastic.Contains(t, body, `<div class="resilience">retries=0 hedges=0 short_circuited=3</div>`)
+		testastic.Equal(t, uint64(2), backend.Requests())
+	})
+
+	t.Run("canceled half-open trial lets the next trial through", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a target whose circuit opened after a failed sample and is ready for a trial
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.FailNext(1)
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name: config.DefaultTargetName,
+				Resilience: config.ResilienceConfig{
+					BreakerThreshold: 1,
+					BreakerOpenFor:   50 * time.Millisecond,
+				},
+			}}
+		})
+		defer frontend.Close()
+
+		failed := httpGet(t, frontend.URL+"/tiles?count=1")
+		_ = failed.Body.Close()
+
+		time.Sleep(100 * time.Millisecond)
+
+		// WHEN: the trial sample runs out of time and another sample follows
+		backend.DelayNext(1, time.Second)
+
+		canceled := httpGet(t, frontend.URL+"/tiles?count=1&timeout=50ms")
+		_ = canceled.Body.Close()
+
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the next sample is the new trial, reaches the backend and closes the circuit
+		body := readBody(t, resp)
+		testastic.Contains(t, body, "saw 1 of ~1 instances")
+		testastic.NotContains(t, body, `class="resilience"`)
+		testastic.Equal(t, uint64(3), backend.Requests())
+	})
+
+	t.Run("outcome of a call admitted before the circuit opened does not decide the trial", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a slow sample in flight while the next sample fails and opens the circuit
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.DelayRequest(0, 400*time.Millisecond)
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name: config.DefaultTargetName,
+				Resilience: config.ResilienceConfig{
+					BreakerThreshold: 1,
+					BreakerOpenFor:   50 * time.Millisecond,
+				},
+			}}
+		})
+		defer frontend.Close()
+
+		slow := make(chan struct{})
+
+		go func() {
+			defer close(slow)
+
+			resp := httpGet(t, frontend.URL+"/tiles?count=1&timeout=5s")
+			_ = resp.Body.Close()
+		}()
+
+		time.Sleep(50 * time.Millisecond)
+		backend.FailNext(1)
+
+		failed := httpGet(t, frontend.URL+"/tiles?count=1")
+		_ = failed.Body.Close()
+
+		time.Sleep(100 * time.Millisecond)
+
+		// WHEN: a slow half-open trial starts and the old sample succeeds while it runs
+		backend.DelayNext(1, time.Second)
+
+		trial := make(chan struct{})
+
+		go func() {
+			defer close(trial)
+
+			resp := httpGet(t, frontend.URL+"/tiles?count=1&timeout=4s")
+			_ = resp.Body.Close()
+		}()
+
+		<-slow
+
+		resp := httpGet(t, frontend.URL+"/tiles?count=1&timeout=3s")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the circuit stays half-open until the trial ends and short-circuits the next sample
+		body := readBody(t, resp)
+		testastic.Contains(t, body, `<div class="resilience">retries=0 hedges=0 short_circuited=1</div>`)
+		testastic.Equal(t, uint64(3), backend.Requests())
+
+		<-trial
+	})
+}
@@ -0,0 +1,118 @@
+package integration_test
+
+import (
+	"net/http"
+	"phasor-frontend/internal/config"
+	"testing"
+	"time"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestRestartDetection(t *testing.T) {
+	t.Parallel()
+
+	t.Run("uptime going backwards is flagged as a restart", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend that sampled an instance running for an hour
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.SetUptime(time.Hour)
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		tiles := httpGet(t, frontend.URL+"/tiles?count=1")
+		_ = tiles.Body.Close()
+
+		// WHEN: the instance restarts and is sampled again
+		backend.SetUptime(0)
+
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		body := readBody(t, resp)
+
+		// THEN: the tile is flagged and the restart shows up in the event feed
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+		testastic.Contains(t, body, `<div class="flag">restarted</div>`)
+
+		events := httpGet(t, frontend.URL+"/events")
+		defer events.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		testastic.Equal(t, http.StatusOK, events.StatusCode)
+		testastic.AssertHTML(t, testdataPath("frontend_events_restart", "expected_response.html"), events.Body)
+	})
+
+	t.Run("repeated restarts within the window are flagged as a crash loop", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend that flags two restarts within a minute as a crash loop
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Inventory = config.InventoryConfig{
+				RestartWindow:      time.Minute,
+				CrashLoopThreshold: 2,
+			}
+		})
+		defer frontend.Close()
+
+		// WHEN: the instance restarts twice between samples
+		var body string
+
+		for _, uptime := range []time.Duration{time.Hour, time.Minute, time.Second} {
+			backend.SetUptime(uptime)
+
+			resp := httpGet(t, frontend.URL+"/tiles?count=1")
+			body = readBody(t, resp)
+			_ = resp.Body.Close()
+		}
+
+		// THEN: the tile is flagged as crash looping
+		testastic.Contains(t, body, `<div class="flag">crash looping: 2 restarts</div>`)
+	})
+
+	t.Run("samples recorded out of order are not flagged as restarts", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a request whose first sample is fast and whose second sample is slow
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		backend.SetUptime(time.Hour)
+		backend.DelayRequest(1, 300*time.Millisecond)
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		slow := make(chan struct{})
+
+		go func() {
+			defer close(slow)
+
+			resp := httpGet(t, frontend.URL+"/tiles?count=2")
+			_ = resp.Body.Close()
+		}()
+
+		// WHEN: an overlapping request records a larger uptime before the slow request
+		// records the smaller uptime of its first sample
+		time.Sleep(100 * time.Millisecond)
+
+		fast := httpGet(t, frontend.URL+"/tiles?count=1")
+		_ = fast.Body.Close()
+
+		<-slow
+
+		// THEN: the instance kept its start time and no restart is reported
+		testastic.Equal(t, 0, fetchInventory(t, frontend.URL).Instances[0].Restarts)
+
+		events := httpGet(t, frontend.URL+"/events.json")
+		defer events.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		testastic.NotContains(t, readBody(t, events), `"kind"`)
+	})
+}
@@ -0,0 +1,95 @@
+package integration_test
+
+import (
+	"phasor-frontend/internal/config"
+	"regexp"
+	"testing"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestRevisionGrouping(t *testing.T) {
+	t.Parallel()
+
+	t.Run("kubernetes pod names are grouped by ReplicaSet revision", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a stable and a canary ReplicaSet that report the same version
+		backend := newMockBackendWithHostnames("1.0.0",
+			"phasor-backend-7d9f8c6b5-x2kqz",
+			"phasor-backend-7d9f8c6b5-bq4mz",
+			"phasor-backend-5c8d7f9b4-k7wzn",
+		)
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: tiles are requested
+		resp := httpGet(t, frontend.URL+"/tiles?count=6")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the summary groups samples by revision and tiles show their revision
+		body := readBody(t, resp)
+		testastic.Contains(t, body,
+			`<div class="revision">phasor-backend 7d9f8c6b5: 4 samples from 2 pods, versions 1.0.0</div>`)
+		testastic.Contains(t, body,
+			`<div class="revision">phasor-backend 5c8d7f9b4: 2 samples from 1 pods, versions 1.0.0</div>`)
+		testastic.Contains(t, body, "<div>Revision: 5c8d7f9b4</div>")
+
+		// THEN: pods of the same revision share their tile color
+		testastic.Equal(t, revisionColor(t, body, "x2kqz"), revisionColor(t, body, "bq4mz"))
+	})
+
+	t.Run("configured hostname patterns replace kubernetes pod naming", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: hostnames that follow a custom naming scheme
+		backend := newMockBackendWithHostnames("1.0.0", "backend.v42.node7")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.HostnamePatterns = []string{`^(?P<workload>[a-z]+)\.(?P<revision>v\d+)\.(?P<pod>.+)$`}
+		})
+		defer frontend.Close()
+
+		// WHEN: tiles are requested
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the revision is taken from the configured pattern
+		testastic.Contains(t, readBody(t, resp),
+			`<div class="revision">backend v42: 1 samples from 1 pods, versions 1.0.0</div>`)
+	})
+
+	t.Run("hostnames that match no pattern have no revision", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a hostname that is not a pod name
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: tiles are requested
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: no revision is shown
+		body := readBody(t, resp)
+		testastic.NotContains(t, body, `class="revision"`)
+		testastic.NotContains(t, body, "Revision:")
+	})
+}
+
+// revisionColor returns the revision color of the tile of the pod with the given suffix.
+func revisionColor(t *testing.T, body, suffix string) string {
+	t.Helper()
+
+	match := regexp.MustCompile(`border-right: 6px solid ([^;]+);">\s*<h3><span[^>]*>[^<]*-` + suffix + `<`).
+		FindStringSubmatch(body)
+	testastic.True(t, match != nil)
+
+	return match[1]
+}
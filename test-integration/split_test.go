@@ -0,0 +1,105 @@
+package integration_test
+
+import (
+	"net/http"
+	"phasor-frontend/internal/config"
+	"phasor-frontend/testutil"
+	"testing"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestExpectedSplit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("deviation from configured split is flagged", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a default target expecting an 80/20 split, while only one version answers
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		cfg := &config.Config{
+			BackendURL:  backend.URL() + "/instance/info",
+			Environment: "test",
+			TileColors:  defaultTileColors,
+			Targets: []config.TargetConfig{
+				{Name: config.DefaultTargetName, ExpectedSplit: map[string]float64{"1.0.0": 80, "2.0.0": 20}},
+			},
+		}
+
+		frontend, err := testutil.NewTestServerWithConfig(t.Context(), cfg, templatesPath(), testutil.NewTestLogger(t))
+		testastic.NoError(t, err)
+
+		defer frontend.Close()
+
+		// WHEN: requesting 20 tiles
+		resp := httpGet(t, frontend.URL+"/tiles?count=20")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: both versions fall outside their intervals and the deviation is significant
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body := readBody(t, resp)
+		testastic.Contains(t, body, `<div class="split">p = 0.025, significant, approximate</div>`)
+		testastic.Contains(t, body, "1.0.0: 20 (100%, interval 84-100%), expected 80%, deviates")
+		testastic.Contains(t, body, "2.0.0: 0 (0%, interval 0-16%), expected 20%, deviates")
+	})
+
+	t.Run("split from query parameter within sampling noise is not flagged", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend server connected to a single-version backend
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend, err := testutil.NewTestServer(
+			t.Context(),
+			backend.URL()+"/instance/info",
+			defaultTileColors,
+			templatesPath(),
+			testutil.NewTestLogger(t),
+		)
+		testastic.NoError(t, err)
+
+		defer frontend.Close()
+
+		// WHEN: requesting 20 tiles expecting a 95/5 split
+		resp := httpGet(t, frontend.URL+"/tiles?count=20&expect=1.0.0:95,2.0.0:5")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the missing 5% version is explained by sampling noise
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body := readBody(t, resp)
+		testastic.Contains(t, body, `<div class="split">p = 0.305, approximate</div>`)
+		testastic.Contains(t, body, "1.0.0: 20 (100%, interval 84-100%), expected 95%</div>")
+		testastic.Contains(t, body, "2.0.0: 0 (0%, interval 0-16%), expected 5%</div>")
+	})
+
+	t.Run("pattern keys compare version families", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: two stable patch versions and a canary release candidate behind one target
+		backend := newMockBackendWithHostnames("1.2.0", "web-1", "web-2", "web-3", "web-4")
+		defer backend.Close()
+
+		backend.SetHostVersions(map[string]string{"web-2": "1.2.1", "web-3": "1.3.0-rc.1", "web-4": "1.3.0-rc.1"})
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: requesting 20 tiles expecting an even split between the 1.2 and 1.3 families
+		resp := httpGet(t, frontend.URL+"/tiles?count=20&expect=1.2.*:50,1.3.*:50")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the versions of each family are compared as one share
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body := readBody(t, resp)
+		testastic.Contains(t, body, "1.2.*: 10 (50%, interval 30-70%), expected 50%</div>")
+		testastic.Contains(t, body, "1.3.*: 10 (50%, interval 30-70%), expected 50%</div>")
+		testastic.NotContains(t, body, `<div class="version-share">1.2.0`)
+		testastic.NotContains(t, body, "deviates")
+	})
+}
@@ -0,0 +1,81 @@
+package integration_test
+
+import (
+	"phasor-frontend/internal/config"
+	"testing"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestStickySampling(t *testing.T) {
+	t.Parallel()
+
+	t.Run("cookie affinity keeps every client on one instance", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: an ingress with cookie-based session affinity in front of three pods
+		backend := newMockBackendWithHostnames("1.0.0", "pod-a", "pod-b", "pod-c")
+		defer backend.Close()
+
+		backend.SetStickyCookie("route")
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: three simulated clients take three samples each
+		resp := httpGet(t, frontend.URL+"/tiles?mode=sticky&count=3&clients=3")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: every client is reported as sticky
+		body := readBody(t, resp)
+		testastic.Contains(t, body, `<div class="stickiness">3 of 3 clients sticky, ratio 100% by cookie jar</div>`)
+		testastic.Contains(t, body, `<div class="client">client 1: 3 samples on pod-a, sticky</div>`)
+		testastic.Contains(t, body, "<div>Client: 3</div>")
+	})
+
+	t.Run("missing affinity is reported as not sticky", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a load balancer without session affinity in front of three pods
+		backend := newMockBackendWithHostnames("1.0.0", "pod-a", "pod-b", "pod-c")
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: two simulated clients take three samples each
+		resp := httpGet(t, frontend.URL+"/tiles?mode=sticky&count=3&clients=2")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: no client is sticky
+		body := readBody(t, resp)
+		testastic.Contains(t, body, `<div class="stickiness">0 of 2 clients sticky, ratio 33% by cookie jar</div>`)
+		testastic.Contains(t, body, `<div class="client">client 2: 3 samples on pod-a,pod-b,pod-c</div>`)
+	})
+
+	t.Run("affinity header identifies the session of every client", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a mesh that hashes an affinity header to pick a pod
+		backend := newMockBackendWithHostnames("1.0.0", "pod-a", "pod-b", "pod-c")
+		defer backend.Close()
+
+		backend.SetAffinityHeader("x-session-id")
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name:           config.DefaultTargetName,
+				AffinityHeader: "x-session-id",
+			}}
+		})
+		defer frontend.Close()
+
+		// WHEN: two simulated clients take three samples each
+		resp := httpGet(t, frontend.URL+"/tiles?mode=sticky&count=3&clients=2")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: every client is sticky
+		testastic.Contains(t, readBody(t, resp),
+			`<div class="stickiness">2 of 2 clients sticky, ratio 100% by header x-session-id</div>`)
+	})
+}
@@ -0,0 +1,55 @@
+package integration_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/monkescience/testastic"
+	"phasor-frontend/testutil"
+)
+
+func TestStreamedTiles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("tiles are streamed as out-of-band fragments followed by the summary", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend in front of a healthy backend
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		frontend, err := testutil.NewTestServer(
+			t.Context(),
+			backend.URL()+"/instance/info",
+			defaultTileColors,
+			templatesPath(),
+			testutil.NewTestLogger(t),
+		)
+		testastic.NoError(t, err)
+
+		defer frontend.Close()
+
+		// WHEN: requesting three streamed tiles
+		resp := httpGet(t, frontend.URL+"/tiles?count=3&stream=true")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the container is cleared, every tile is appended on its own and the
+		// fully rendered tiles replace the container at the end
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+		testastic.Equal(t, "true", resp.Header.Get("X-Tiles-Stream"))
+
+		fragments := strings.Split(strings.TrimSpace(readBody(t, resp)), "<!-- end of fragment -->")
+		testastic.Equal(t, 6, len(fragments))
+		testastic.Equal(t, `<div id="tiles-container" hx-swap-oob="innerHTML"></div>`, fragments[0])
+
+		for _, fragment := range fragments[1:4] {
+			testastic.Contains(t, fragment, `<div hx-swap-oob="beforeend:#tiles-container">`)
+			testastic.Contains(t, fragment, `<div class="tile"`)
+		}
+
+		testastic.Contains(t, fragments[4], `<div id="tiles-container" hx-swap-oob="innerHTML">`)
+		testastic.Contains(t, fragments[4], "saw 1 of ~1 instances")
+		testastic.Equal(t, "", strings.TrimSpace(fragments[5]))
+	})
+}
@@ -0,0 +1,156 @@
+package integration_test
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"phasor-frontend/internal/config"
+	"phasor-frontend/internal/discovery"
+	"testing"
+
+	"github.com/monkescience/testastic"
+)
+
+func TestPodTargets(t *testing.T) {
+	t.Parallel()
+
+	t.Run("srv records render one tile per pod", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: two backend pods published as SRV records of a headless service
+		podA := newMockBackendWithHostname("1.0.0", "pod-a")
+		defer podA.Close()
+
+		podB := newMockBackendWithHostname("1.1.0", "pod-b")
+		defer podB.Close()
+
+		dns := newMockDNS(t)
+		defer dns.Close()
+
+		hostA, portA := hostPort(t, podA.URL())
+		hostB, portB := hostPort(t, podB.URL())
+		dns.SetA("pod-a.test.", hostA)
+		dns.SetA("pod-b.test.", hostB)
+		dns.SetSRV("backend.test.",
+			mockSRVRecord{target: "pod-a.test.", port: uint16(portA)},
+			mockSRVRecord{target: "pod-b.test.", port: uint16(portB)},
+		)
+
+		frontend := newColorServer(t, podA.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name: "pods",
+				Mode: config.TargetModeDNS,
+				DNS: config.DNSConfig{
+					Name:       "backend.test",
+					RecordType: "srv",
+					Server:     dns.Addr(),
+				},
+			}}
+		})
+		defer frontend.Close()
+
+		// WHEN: requesting tiles of the pod target
+		resp := httpGet(t, frontend.URL+"/tiles?target=pods")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: every pod is shown once with its reachability
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+		testastic.AssertHTML(t, testdataPath("frontend_tiles_pods_srv", "expected_response.html"), resp.Body)
+	})
+
+	t.Run("a records show unreachable pods", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a headless service resolving to one reachable and one unreachable pod
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		dns := newMockDNS(t)
+		defer dns.Close()
+
+		_, port := hostPort(t, backend.URL())
+		dns.SetA("headless.test.", "127.0.0.1", "127.0.0.2")
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name: "pods",
+				Mode: config.TargetModeDNS,
+				DNS: config.DNSConfig{
+					Name:   "headless.test",
+					Port:   port,
+					Server: dns.Addr(),
+				},
+			}}
+		})
+		defer frontend.Close()
+
+		// WHEN: requesting tiles of the pod target
+		resp := httpGet(t, frontend.URL+"/tiles?target=pods")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the unreachable pod is rendered as an error tile with its address
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+		testastic.AssertHTML(t, testdataPath("frontend_tiles_pods_a", "expected_response.html"), resp.Body)
+	})
+
+	t.Run("failed pod discovery is reported", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a pod target whose DNS name has no records
+		backend := newMockBackend("1.0.0")
+		defer backend.Close()
+
+		dns := newMockDNS(t)
+		defer dns.Close()
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{
+				Name: "pods",
+				Mode: config.TargetModeDNS,
+				DNS: config.DNSConfig{
+					Name:   "missing.test",
+					Port:   8080,
+					Server: dns.Addr(),
+				},
+			}}
+		})
+		defer frontend.Close()
+
+		// WHEN: requesting tiles of the pod target
+		resp := httpGet(t, frontend.URL+"/tiles?target=pods")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the discovery error is shown instead of tiles
+		testastic.Equal(t, http.StatusOK, resp.StatusCode)
+		testastic.AssertHTML(t, testdataPath("frontend_tiles_pods_dns_error", "expected_response.html"), resp.Body)
+	})
+
+	t.Run("short names are resolved through the resolv.conf search list", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a resolv.conf whose first nameserver is down and whose search list
+		// qualifies a short Service name
+		dns := newMockDNS(t)
+		defer dns.Close()
+
+		dns.SetA("backend.default.svc.cluster.local.", "127.0.0.1")
+
+		down, err := net.ListenPacket("udp", "127.0.0.1:0")
+		testastic.NoError(t, err)
+
+		downAddr := down.LocalAddr().String()
+		testastic.NoError(t, down.Close())
+
+		resolvConf := fmt.Sprintf("nameserver %s\nnameserver %s\nsearch default.svc.cluster.local svc.cluster.local\n"+
+			"options ndots:5\n", downAddr, dns.Addr())
+		resolver := discovery.NewResolverFromConfig(writeTestFile(t, t.TempDir(), "resolv.conf", []byte(resolvConf)))
+
+		// WHEN: looking up the short name
+		addrs, _, err := resolver.LookupHosts(t.Context(), "backend")
+
+		// THEN: the name is expanded with the search list on the nameserver that answers
+		testastic.NoError(t, err)
+		testastic.Equal(t, 1, len(addrs))
+		testastic.Equal(t, "127.0.0.1", addrs[0].String())
+	})
+}
@@ -0,0 +1,238 @@
+package integration_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"phasor-frontend/internal/config"
+	"testing"
+	"time"
+
+	"github.com/monkescience/testastic"
+)
+
+const backendServerName = "backend.internal"
+
+func TestBackendTLS(t *testing.T) {
+	t.Parallel()
+
+	t.Run("samples and health checks use the CA bundle and client certificate", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a backend with a private CA that requires client certificates
+		serverCA := newTestCA(t, "server-ca")
+		clientCA := newTestCA(t, "client-ca")
+
+		backend := newMockTLSBackend("1.0.0", backendTLSConfig(t, serverCA, clientCA))
+		defer backend.Close()
+
+		dir := t.TempDir()
+		tlsCfg := config.TLSConfig{
+			CAFile:     writeTestFile(t, dir, "ca.pem", serverCA.certPEM),
+			CertFile:   filepath.Join(dir, "client.pem"),
+			KeyFile:    filepath.Join(dir, "client-key.pem"),
+			ServerName: backendServerName,
+		}
+		clientCA.issueTo(t, tlsCfg.CertFile, tlsCfg.KeyFile)
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{Name: config.DefaultTargetName, TLS: tlsCfg}}
+		})
+		defer frontend.Close()
+
+		// WHEN: tiles and the readiness are requested
+		tiles := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer tiles.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		ready := httpGet(t, frontend.URL+"/health/ready")
+		defer ready.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the backend is reached over mutual TLS
+		body := readBody(t, tiles)
+		testastic.Contains(t, body, "test-host")
+		testastic.NotContains(t, body, "error")
+		testastic.Equal(t, http.StatusOK, ready.StatusCode)
+	})
+
+	t.Run("backend with a private CA is unreachable without the CA bundle", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a backend with a private CA and a frontend without TLS settings
+		serverCA := newTestCA(t, "server-ca")
+
+		backend := newMockTLSBackend("1.0.0", backendTLSConfig(t, serverCA, nil))
+		defer backend.Close()
+
+		frontend := newColorServer(t, backend.URL(), nil)
+		defer frontend.Close()
+
+		// WHEN: tiles are requested
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the sample fails
+		testastic.Contains(t, readBody(t, resp), "<div>Uptime: N/A</div>")
+	})
+
+	t.Run("rotated client certificate is picked up without a restart", func(t *testing.T) {
+		t.Parallel()
+
+		// GIVEN: a frontend whose client certificate is signed by a CA the backend does not trust
+		serverCA := newTestCA(t, "server-ca")
+		clientCA := newTestCA(t, "client-ca")
+		untrustedCA := newTestCA(t, "untrusted-ca")
+
+		backend := newMockTLSBackend("1.0.0", backendTLSConfig(t, serverCA, clientCA))
+		defer backend.Close()
+
+		dir := t.TempDir()
+		tlsCfg := config.TLSConfig{
+			CAFile:     writeTestFile(t, dir, "ca.pem", serverCA.certPEM),
+			CertFile:   filepath.Join(dir, "client.pem"),
+			KeyFile:    filepath.Join(dir, "client-key.pem"),
+			ServerName: backendServerName,
+		}
+		untrustedCA.issueTo(t, tlsCfg.CertFile, tlsCfg.KeyFile)
+
+		frontend := newColorServer(t, backend.URL(), func(cfg *config.Config) {
+			cfg.Targets = []config.TargetConfig{{Name: config.DefaultTargetName, TLS: tlsCfg}}
+		})
+		defer frontend.Close()
+
+		rejected := httpGet(t, frontend.URL+"/tiles?count=1")
+		testastic.Contains(t, readBody(t, rejected), "<div>Uptime: N/A</div>")
+		_ = rejected.Body.Close()
+
+		// WHEN: the client certificate is replaced on disk by a trusted one
+		clientCA.issueTo(t, tlsCfg.CertFile, tlsCfg.KeyFile)
+
+		later := time.Now().Add(time.Minute)
+		testastic.NoError(t, os.Chtimes(tlsCfg.CertFile, later, later))
+		testastic.NoError(t, os.Chtimes(tlsCfg.KeyFile, later, later))
+
+		resp := httpGet(t, frontend.URL+"/tiles?count=1")
+		defer resp.Body.Close() //nolint:errcheck // Ignoring close error in test cleanup.
+
+		// THEN: the next handshake presents the new certificate
+		testastic.NotContains(t, readBody(t, resp), "<div>Uptime: N/A</div>")
+	})
+}
+
+// testCA is a certificate authority for TLS tests.
+type testCA struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testastic.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	testastic.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	testastic.NoError(t, err)
+
+	return &testCA{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue creates a certificate and key signed by the CA, for the server name when set
+// and for client authentication otherwise.
+func (ca *testCA) issue(t *testing.T, serverName string) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testastic.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "phasor-frontend"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	if serverName != "" {
+		template.Subject.CommonName = serverName
+		template.DNSNames = []string{serverName}
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	testastic.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	testastic.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// issueTo writes a client certificate and key signed by the CA to the given files.
+func (ca *testCA) issueTo(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+
+	certPEM, keyPEM := ca.issue(t, "")
+	testastic.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	testastic.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+}
+
+// backendTLSConfig creates the TLS configuration of a backend with a certificate for
+// backendServerName. Client certificates signed by clientCA are required when it is set.
+func backendTLSConfig(t *testing.T, serverCA, clientCA *testCA) *tls.Config {
+	t.Helper()
+
+	certPEM, keyPEM := serverCA.issue(t, backendServerName)
+
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	testastic.NoError(t, err)
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCA != nil {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		tlsConfig.ClientCAs = x509.NewCertPool()
+		tlsConfig.ClientCAs.AddCert(clientCA.cert)
+	}
+
+	return tlsConfig
+}
+
+func writeTestFile(t *testing.T, dir, name string, content []byte) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	testastic.NoError(t, os.WriteFile(path, content, 0o600))
+
+	return path
+}
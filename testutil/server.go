@@ -2,6 +2,7 @@
 package testutil
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http/httptest"
@@ -11,7 +12,9 @@ import (
 
 // NewTestServer creates a fully configured test server with the same middleware
 // and routing as production. Returns an httptest.Server ready for integration tests.
+// Background work of the server stops when ctx is canceled.
 func NewTestServer(
+	ctx context.Context,
 	backendURL string,
 	tileColors []string,
 	templatesPath string,
@@ -23,7 +26,17 @@ func NewTestServer(
 		TileColors:  tileColors,
 	}
 
-	router, err := app.SetupRouter(cfg, templatesPath, logger)
+	return NewTestServerWithConfig(ctx, cfg, templatesPath, logger)
+}
+
+// NewTestServerWithConfig creates a test server like NewTestServer from a complete configuration.
+func NewTestServerWithConfig(
+	ctx context.Context,
+	cfg *config.Config,
+	templatesPath string,
+	logger *slog.Logger,
+) (*httptest.Server, error) {
+	router, err := app.SetupRouter(ctx, cfg, templatesPath, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to setup router: %w", err)
 	}